@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/scottames/cmder"
 	"github.com/scottames/cmder/pkg/log"
@@ -52,11 +53,28 @@ func Dryrun3() error {
 // Set environment variables
 //
 // Note: piping supported if passed to a shell
-//   - alternatively us cmd.Output + cmd.In
+//   - alternatively use cmder.Pipe (see Pipe)
 func Env() error {
 	return cmder.New("bash", "-c", "env | grep -i foo").Env("FOO=bar", "BAR=foo").Run()
 }
 
+// Run multiple commands concurrently, at most two at a time, canceling the rest on failure
+func Group() error {
+	return cmder.NewGroup(
+		cmder.New("echo", "uno"),
+		cmder.New("echo", "dos"),
+		cmder.New("echo", "tres"),
+	).Limit(2).FailFast().Run()
+}
+
+// Run multiple commands concurrently with their output prefixed by a label
+func Prefix() error {
+	return cmder.NewGroup(
+		cmder.New("bash", "-c", "for i in 1 2 3; do echo $i; sleep 0.1; done").Prefix("uno"),
+		cmder.New("bash", "-c", "for i in 1 2 3; do echo $i; sleep 0.1; done").Prefix("dos"),
+	).Run()
+}
+
 // Execute a command and inspect the exit code 0
 func ExitCode0() error {
 	command := cmder.New("echo")
@@ -206,6 +224,30 @@ func Fn() error {
 	return echo("tres")
 }
 
+// Pipe the output of one command into the next (echo foo | tr o 0)
+func Pipe() error {
+	return cmder.Pipe(
+		cmder.New("echo", "foo"),
+		cmder.New("tr", "o", "0"),
+	).Run()
+}
+
+// Retry a flaky command up to 5 times with exponential backoff
+func Retry() error {
+	return cmder.New("bash", "-c", "exit $((RANDOM % 2))").
+		Retry(cmder.RetryPolicy{
+			MaxAttempts: 5,
+			Backoff:     cmder.ExponentialBackoff(100*time.Millisecond, 2*time.Second),
+			Jitter:      0.1,
+		}).
+		Run()
+}
+
+// Run a shell script with safely quoted arguments (bash -c with set -euo pipefail)
+func Sh(s string) error {
+	return cmder.Sh("echo %s | tr a-z A-Z", s).Run()
+}
+
 // Execute a command
 func Run(s string) error {
 	return cmder.New("echo", s).Run()
@@ -0,0 +1,54 @@
+package cmder
+
+import "sync"
+
+// tailBuffer implements io.Writer retaining only the last size bytes written to it
+//
+// A size less than or equal to zero retains nothing.
+type tailBuffer struct {
+	buf  []byte
+	mu   sync.Mutex
+	size int
+}
+
+// newTailBuffer returns a new tailBuffer retaining the last size bytes
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+// Write implements the io.Writer interface
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	if b.size <= 0 {
+		return len(p), nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(p) >= b.size {
+		b.buf = append(b.buf[:0], p[len(p)-b.size:]...)
+		return len(p), nil
+	}
+
+	b.buf = append(b.buf, p...)
+
+	// compact once the buffer has grown to twice the retained size
+	if len(b.buf) >= 2*b.size {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.size:]...)
+	}
+
+	return len(p), nil
+}
+
+// Bytes returns a copy of the retained bytes
+func (b *tailBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	start := 0
+	if len(b.buf) > b.size {
+		start = len(b.buf) - b.size
+	}
+
+	return append([]byte(nil), b.buf[start:]...)
+}
@@ -0,0 +1,83 @@
+package cmder
+
+import "sync"
+
+// captureBuffer implements io.Writer retaining the bytes written to it, or only the last
+// limit bytes if limit is greater than zero, see also: Cmder.Capture
+type captureBuffer struct {
+	buf  []byte
+	mu   sync.Mutex
+	tail *tailBuffer
+}
+
+// newCaptureBuffer returns a new captureBuffer retaining the last limit bytes
+// unlimited if limit is less than or equal to zero
+func newCaptureBuffer(limit int) *captureBuffer {
+	if limit > 0 {
+		return &captureBuffer{tail: newTailBuffer(limit)}
+	}
+
+	return &captureBuffer{}
+}
+
+// Write implements the io.Writer interface
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	if b.tail != nil {
+		return b.tail.Write(p)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+
+	return len(p), nil
+}
+
+// Bytes returns a copy of the retained bytes
+func (b *captureBuffer) Bytes() []byte {
+	if b.tail != nil {
+		return b.tail.Bytes()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]byte(nil), b.buf...)
+}
+
+// captured the buffers capturing the output of a cmd, see also: Cmder.Capture
+type captured struct {
+	combined *captureBuffer
+	stderr   *captureBuffer
+	stdout   *captureBuffer
+}
+
+// captureOutput tees the stdout and stderr of the exec.Cmd to new buffers, if Capture is set
+func (c *cmd) captureOutput() {
+	c.captured = nil
+
+	if !c.capture {
+		return
+	}
+
+	c.captured = &captured{
+		combined: newCaptureBuffer(c.captureLimit),
+		stderr:   newCaptureBuffer(c.captureLimit),
+		stdout:   newCaptureBuffer(c.captureLimit),
+	}
+
+	c.separateOutput()
+
+	c.cmd.Stdout = tee(c.cmd.Stdout, c.captured.stdout, c.captured.combined)
+	c.cmd.Stderr = tee(c.cmd.Stderr, c.captured.stderr, c.captured.combined)
+}
+
+// capturedBytes returns the bytes captured by the buffer returned by fn, if any
+func (c *cmd) capturedBytes(fn func(*captured) *captureBuffer) []byte {
+	if c.captured == nil {
+		return nil
+	}
+
+	return fn(c.captured).Bytes()
+}
@@ -0,0 +1,95 @@
+package cmder_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_Capture(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	c := cmder.New("sh", "-c", "echo out; echo err >&2; echo out2").Capture().Out(&stdout, &stderr).Silent()
+
+	assert.Nil(t, c.Stdout(), "Expected nil prior to execution")
+
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "out\nout2\n", string(c.Stdout()))
+	assert.Equal(t, "err\n", string(c.Stderr()))
+	assert.ElementsMatch(t, []string{"out", "err", "out2"}, strings.Fields(string(c.Combined())))
+	assert.Equal(t, "out\nout2\n", stdout.String(), "Expected stdout to be written")
+	assert.Equal(t, "err\n", stderr.String(), "Expected stderr to be written")
+}
+
+func Test_CaptureLimit(t *testing.T) {
+	c := cmder.New("sh", "-c", "printf 0123456789; printf abcdefghij >&2").
+		Capture(4).
+		Out(&bytes.Buffer{}).
+		Silent()
+
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "6789", string(c.Stdout()))
+	assert.Equal(t, "ghij", string(c.Stderr()))
+	assert.Len(t, c.Combined(), 4)
+}
+
+func Test_CaptureStartWait(t *testing.T) {
+	c := cmder.New(echo, foo).Capture().Out(&bytes.Buffer{}).Silent()
+
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "foo\n", string(c.Stdout()))
+}
+
+func Test_CaptureOutput(t *testing.T) {
+	c := cmder.New("sh", "-c", "echo out; echo err >&2").Capture()
+
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "out\n", string(out))
+	assert.Equal(t, "out\n", string(c.Stdout()))
+	assert.Equal(t, "err\n", string(c.Stderr()))
+}
+
+func Test_CaptureUnset(t *testing.T) {
+	c := cmder.New(echo, foo).Out(&bytes.Buffer{}).Silent()
+
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, c.Stdout())
+	assert.Nil(t, c.Stderr())
+	assert.Nil(t, c.Combined())
+}
+
+func Test_CaptureString(t *testing.T) {
+	c := cmder.New("sh", "-c", "echo out; echo err >&2").Capture().Silent()
+
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, c.String(), "sh -c echo out; echo err >&2")
+	assert.Equal(t, "out\n", string(c.Stdout()), "Expected String to leave the captured output")
+	assert.Equal(t, "err\n", string(c.Stderr()))
+}
@@ -3,11 +3,14 @@ package cmder
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/scottames/cmder/pkg/log"
@@ -23,9 +26,10 @@ func DryRun() {
 
 // New returns a new os/exec builder command implemented using the Cmder interface
 func New(command ...string) Cmder {
-	c := &cmd{strings: command}
+	c := &cmd{mu: &sync.Mutex{}, strings: command}
 	c.ctx = context.Background()
 	c.env = os.Environ()
+	c.envBase = len(c.env)
 	c.stdin = os.Stdin
 	c.stdout = os.Stdout
 	c.stderr = os.Stderr
@@ -35,27 +39,72 @@ func New(command ...string) Cmder {
 
 // cmd implements the Cmder interface
 type cmd struct {
-	cmd       *exec.Cmd
-	complete  bool
-	ctx       context.Context
-	dryRun    bool
-	dryRunKey string
-	dir       string
-	end       time.Time
-	env       []string
-	exitCode  int
-	failed    bool
-	logger    log.Logger
-	process   *os.Process
-	silent    bool
-	start     time.Time
-	stderr    io.Writer
-	stdin     io.Reader
-	stdout    io.Writer
-	strings   []string
+	action        string
+	attempt       int
+	attempts      []Attempt
+	buildErr      error
+	cancelSignal  os.Signal
+	capture       bool
+	captureLimit  int
+	captured      *captured
+	cmd           *exec.Cmd
+	complete      bool
+	ctx           context.Context
+	dryRun        bool
+	dryRunErr     error
+	dryRunKey     string
+	dir           string
+	done          chan struct{}
+	end           time.Time
+	env           []string
+	envBase       int
+	envCleared    bool
+	envFiles      []string
+	envUnset      []string
+	executor      Executor
+	expand        bool
+	expandStrict  bool
+	exitCode      int
+	failed        bool
+	glob          bool
+	globMode      GlobMode
+	gracePeriod   time.Duration
+	killed        bool
+	lineWriters   []*lineWriter
+	logger        log.Logger
+	mu            *sync.Mutex
+	mux           *Mux
+	onStderrLine  func(string)
+	onStdoutLine  func(string)
+	pdeathsig     syscall.Signal
+	prefix        string
+	prefixWriters []io.Closer
+	proc          *exec.Cmd
+	process       *os.Process
+	processGroup  bool
+	resolved      *resolved
+	retryPolicy   *RetryPolicy
+	script        string
+	shell         []string
+	signal        os.Signal
+	silent        bool
+	start         time.Time
+	started       bool
+	status        Status
+	stderr        io.Writer
+	stderrTail    *tailBuffer
+	stdin         io.Reader
+	stdout        io.Writer
+	strings       []string
+}
+
+func (c *cmd) Attempts() []Attempt {
+	return c.attempts
 }
 
 func (c *cmd) Args(args ...string) Cmder {
+	c.invalidate()
+
 	if len(args) > 0 {
 		c.strings = append(c.strings, args...)
 	}
@@ -63,16 +112,51 @@ func (c *cmd) Args(args ...string) Cmder {
 	return c
 }
 
+func (c *cmd) CancelSignal(sig os.Signal) Cmder {
+	c.cancelSignal = sig
+	return c
+}
+
+func (c *cmd) Capture(limit ...int) Cmder {
+	c.capture = true
+	c.captureLimit = 0
+
+	if len(limit) > 0 {
+		c.captureLimit = limit[0]
+	}
+
+	return c
+}
+
 func (c *cmd) Clone() Cmder {
 	clone := *c
+	clone.mu = &sync.Mutex{}
+	clone.resolved = nil
+
 	return &clone
 }
 
+func (c *cmd) ClearEnv() Cmder {
+	c.invalidate()
+	c.env = []string{}
+	c.envBase = 0
+	c.envCleared = true
+
+	return c
+}
+
+func (c *cmd) Combined() []byte {
+	return c.capturedBytes(func(b *captured) *captureBuffer { return b.combined })
+}
+
 func (c *cmd) CombinedOutput() ([]byte, error) {
 	var b bytes.Buffer
 	c.stdout = &b
 	c.stderr = &b
-	err := c.Run()
+	err := c.retry(func() error {
+		b.Reset()
+		return c.run(log.LoggerRunKey)
+	})
 
 	return b.Bytes(), err
 }
@@ -87,7 +171,9 @@ func (c *cmd) Ctx(ctx context.Context) Cmder {
 }
 
 func (c *cmd) Dir(dir string) Cmder {
+	c.invalidate()
 	c.dir = dir
+
 	return c
 }
 
@@ -103,14 +189,68 @@ func (c *cmd) Duration() time.Duration {
 }
 
 func (c *cmd) Env(env ...string) Cmder {
+	c.invalidate()
 	c.env = append(c.env, env...)
+
 	return c
 }
 
+func (c *cmd) EnvFile(paths ...string) Cmder {
+	c.invalidate()
+	c.envFiles = append(c.envFiles, paths...)
+
+	return c
+}
+
+func (c *cmd) EnvMap(env map[string]string) Cmder {
+	return c.Env(mapEnv(env)...)
+}
+
+func (c *cmd) Environ() []string {
+	return dedupEnv(c.resolve().env)
+}
+
 func (c *cmd) ExitCode() int {
 	return c.exitCode
 }
 
+func (c *cmd) Executor(e Executor) Cmder {
+	c.executor = e
+	return c
+}
+
+func (c *cmd) Expand() Cmder {
+	c.invalidate()
+	c.expand = true
+
+	return c
+}
+
+func (c *cmd) ExpandStrict() Cmder {
+	c.invalidate()
+	c.expand = true
+	c.expandStrict = true
+
+	return c
+}
+
+func (c *cmd) Glob(mode ...GlobMode) Cmder {
+	c.invalidate()
+	c.glob = true
+	c.globMode = GlobLiteral
+
+	if len(mode) > 0 {
+		c.globMode = mode[0]
+	}
+
+	return c
+}
+
+func (c *cmd) GracePeriod(grace time.Duration) Cmder {
+	c.gracePeriod = grace
+	return c
+}
+
 func (c *cmd) In(input ...byte) Cmder {
 	if input != nil {
 		c.stdin = bytes.NewReader(input)
@@ -121,6 +261,17 @@ func (c *cmd) In(input ...byte) Cmder {
 	return c
 }
 
+func (c *cmd) InheritEnv(keys ...string) Cmder {
+	c.invalidate()
+	c.env = filterEnv(os.Environ(), func(k string) bool {
+		return matchKey(k, keys)
+	})
+	c.envBase = len(c.env)
+	c.envCleared = true
+
+	return c
+}
+
 func (c *cmd) Kill() error {
 	if c.isDryRun() {
 		c.logCmdDryRun(log.LoggerKillKey)
@@ -129,28 +280,15 @@ func (c *cmd) Kill() error {
 
 	c.failed = true
 	c.exitCode = -1
-	log.LoggerKey = log.LoggerKillKey
+	c.setKilled()
 
-	c.LogCmd()
+	c.logCmd(log.LoggerKillKey)
 
-	return c.cmd.Process.Kill()
+	return c.signalProcess(os.Kill)
 }
 
 func (c *cmd) LogCmd() {
-	if c.silent {
-		return
-	}
-
-	if c.logger == nil {
-		c.logger = getLogger()
-	}
-
-	msg := fmt.Sprintf("%v", c.strings)
-	if c.dir != "" {
-		msg += fmt.Sprintf(string(log.LoggerColor)+" in"+string(log.LoggerClear)+" %s", c.dir)
-	}
-
-	c.logger.Log(msg)
+	c.logCmd(c.getAction())
 }
 
 func (c *cmd) Logger(l log.Logger) Cmder {
@@ -158,6 +296,16 @@ func (c *cmd) Logger(l log.Logger) Cmder {
 	return c
 }
 
+func (c *cmd) OnStderrLine(fn func(line string)) Cmder {
+	c.onStderrLine = fn
+	return c
+}
+
+func (c *cmd) OnStdoutLine(fn func(line string)) Cmder {
+	c.onStdoutLine = fn
+	return c
+}
+
 func (c *cmd) Out(stdout io.Writer, stderr ...io.Writer) Cmder {
 	if len(stderr) > 0 {
 		c.stderr = stderr[0]
@@ -171,36 +319,78 @@ func (c *cmd) Out(stdout io.Writer, stderr ...io.Writer) Cmder {
 func (c *cmd) Output() ([]byte, error) {
 	c.Silent()
 
-	if !c.initAndContinue(log.LoggerOutputKey) {
-		return nil, nil
-	}
+	var output []byte
+
+	err := c.retry(func() error {
+		output = nil
 
-	c.clearStdOutStdErr()
-	output, err := c.cmd.Output()
+		if !c.initAndContinue(log.LoggerOutputKey) {
+			var b bytes.Buffer
 
-	return output, c.endState(err)
+			err := c.simulate(&b, c.stderrTail)
+			output = b.Bytes()
+
+			return err
+		}
+
+		if c.buildErr != nil {
+			return c.endState(c.buildErr)
+		}
+
+		c.clearStdOutStdErr()
+
+		return c.runOutput(&output)
+	})
+
+	return output, err
+}
+
+func (c *cmd) Pdeathsig(sig syscall.Signal) Cmder {
+	c.pdeathsig = sig
+	return c
+}
+
+func (c *cmd) Prefix(label string, m ...*Mux) Cmder {
+	c.prefix = label
+	c.mux = defaultMux
+
+	if len(m) > 0 && m[0] != nil {
+		c.mux = m[0]
+	}
+
+	return c
 }
 
 func (c *cmd) Process() *os.Process {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	return c.process
 }
 
+func (c *cmd) ProcessGroup() Cmder {
+	c.processGroup = true
+	return c
+}
+
 func (c *cmd) Pid() *int {
-	if c.process == nil {
+	p := c.Process()
+	if p == nil {
 		return nil
 	}
 
-	return &c.process.Pid
+	return &p.Pid
 }
 
-func (c *cmd) Run(w ...io.Writer) error {
-	if !c.initAndContinue(log.LoggerRunKey, w...) {
-		return nil
-	}
-
-	err := c.cmd.Run()
+func (c *cmd) Retry(policy RetryPolicy) Cmder {
+	c.retryPolicy = &policy
+	return c
+}
 
-	return c.endState(err)
+func (c *cmd) Run(w ...io.Writer) error {
+	return c.retry(func() error {
+		return c.run(log.LoggerRunKey, w...)
+	})
 }
 
 func (c *cmd) RunFn(w ...io.Writer) func(args ...string) error {
@@ -218,24 +408,38 @@ func (c *cmd) RunFnCmd(w ...io.Writer) func(args ...string) (Cmder, error) {
 	}
 }
 
+func (c *cmd) ShellString() string {
+	r := c.resolve()
+	return shellCmd(r.dir, r.delta, r.args)
+}
+
+func (c *cmd) Signal() os.Signal {
+	return c.signal
+}
+
 func (c *cmd) Silent() Cmder {
 	c.silent = true
 	return c
 }
 
 func (c *cmd) Start(w ...io.Writer) error {
-	log.LoggerKey = log.LoggerStartKey
-
 	if !c.initAndContinue(log.LoggerStartKey, w...) {
+		c.dryRunErr = c.simulate(c.cmd.Stdout, c.cmd.Stderr)
 		return nil
 	}
 
-	err := c.cmd.Start()
+	if c.buildErr != nil {
+		return c.endState(c.buildErr)
+	}
+
+	err := c.getExecutor().Start(c.cmd)
 	if err != nil {
-		return err
+		return c.endState(err)
 	}
 
-	c.process = c.cmd.Process
+	c.setProcess()
+	c.logStarted()
+	c.started = true
 
 	return nil
 }
@@ -255,39 +459,87 @@ func (c *cmd) StartFnCmd(w ...io.Writer) func(args ...string) (Cmder, error) {
 	}
 }
 
+func (c *cmd) Status() Status {
+	return c.status
+}
+
+func (c *cmd) Stderr() []byte {
+	return c.capturedBytes(func(b *captured) *captureBuffer { return b.stderr })
+}
+
+func (c *cmd) Stdout() []byte {
+	return c.capturedBytes(func(b *captured) *captureBuffer { return b.stdout })
+}
+
 func (c *cmd) String() string {
-	return c.buildExec().String()
+	args := c.resolve().args
+	return exec.Command(args[0], args[1:]...).String() //nolint:gosec // never executed
+}
+
+func (c *cmd) Terminate(grace time.Duration) error {
+	if c.isDryRun() {
+		c.logCmdDryRun(log.LoggerKillKey)
+		return nil
+	}
+
+	command, done := c.running()
+	if command == nil {
+		return fmt.Errorf("process expected to be started. found nil process for Terminate")
+	}
+
+	sig := c.terminateSignal()
+	c.logAction(log.LoggerKillKey, "%v terminating (%v), grace period %s", c.strings, sig, grace)
+
+	if err := c.getExecutor().Signal(command, sig); err != nil {
+		return ignoreProcessDone(err)
+	}
+
+	return c.killAfter(command, grace, done)
+}
+
+func (c *cmd) Unsetenv(keys ...string) Cmder {
+	c.invalidate()
+
+	keep := func(k string) bool {
+		return !matchKey(k, keys)
+	}
+
+	base := filterEnv(c.env[:c.envBase], keep)
+	c.env = append(base, filterEnv(c.env[c.envBase:], keep)...)
+	c.envBase = len(base)
+	c.envUnset = append(c.envUnset, keys...)
+
+	return c
 }
 
 func (c *cmd) Wait() error {
-	log.LoggerKey = log.LoggerWaitKey
+	c.action = log.LoggerWaitKey
 
 	if c.isDryRun() {
-		c.logCmdDryRun(log.LoggerKey)
-		return nil
+		c.logCmdDryRun(c.action)
+		return c.dryRunErr
 	}
 
-	c.LogCmd()
+	c.logCmd(c.action)
 
-	if c.process == nil {
+	if !c.started {
 		return fmt.Errorf("process expected to be started. found nil process for Wait")
 	}
 
-	err := c.cmd.Wait()
+	err := c.wait()
 	if err != nil {
 		c.failed = true
-		return err
 	}
 
-	c.end = time.Now()
-	c.complete = true
-
-	return nil
+	return c.endState(err)
 }
 
 // buildExec builds the exec.Cmd for the given cmd
 func (c *cmd) buildExec(w ...io.Writer) *exec.Cmd {
-	command := exec.CommandContext(c.ctx, c.strings[0], c.strings[1:]...) //nolint:gosec // written as intended
+	c.resolved = c.resolve()
+	args := c.resolved.args
+
+	command := exec.CommandContext(c.ctx, args[0], args[1:]...) //nolint:gosec // written as intended
 	c.cmd = command
 
 	switch lw := len(w); {
@@ -302,13 +554,119 @@ func (c *cmd) buildExec(w ...io.Writer) *exec.Cmd {
 		c.cmd.Stderr = c.stderr
 	}
 
-	c.cmd.Dir = c.dir
-	c.cmd.Env = c.env
+	c.prefixOutput()
+
+	// retain the tail of stderr for Error
+	// if stdout and stderr share a writer the tail of the combined output is retained
+	c.stderrTail = newTailBuffer(StderrTailSize)
+
+	switch {
+	case c.cmd.Stderr == nil:
+	case writerEqual(c.cmd.Stdout, c.cmd.Stderr):
+		c.cmd.Stderr = io.MultiWriter(c.cmd.Stderr, c.stderrTail)
+		c.cmd.Stdout = c.cmd.Stderr
+	default:
+		c.cmd.Stderr = io.MultiWriter(c.cmd.Stderr, c.stderrTail)
+	}
+
+	c.teeOutput()
+
+	c.cmd.Dir = c.resolved.dir
+	c.cmd.Env = c.resolved.env
+	c.buildErr = c.resolved.err
 	c.cmd.Stdin = c.stdin
 
+	// bound waiting on output held open by background processes, see also: setCancel
+	c.cmd.WaitDelay = OutputWaitDelay
+
+	c.setCancel()
+	c.setSysProcAttr()
+
 	return c.cmd
 }
 
+// writerEqual returns whether the given writers are equal
+// recovering from the panic caused by comparing uncomparable types
+func writerEqual(a, b io.Writer) (equal bool) {
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+
+	return a == b
+}
+
+// run runs a single attempt of the cmd logging the given action key
+func (c *cmd) run(key string, w ...io.Writer) error {
+	if !c.initAndContinue(key, w...) {
+		return c.simulate(c.cmd.Stdout, c.cmd.Stderr)
+	}
+
+	if c.buildErr != nil {
+		return c.endState(c.buildErr)
+	}
+
+	return c.endState(c.startWait())
+}
+
+// runOutput runs the cmd capturing it's stdout to the given output, as per Output,
+// while teeing it's output, see also: teeOutput
+//
+// stderr is retained for Error as the executor's Output would via exec.ExitError
+func (c *cmd) runOutput(output *[]byte) error {
+	var b bytes.Buffer
+
+	c.cmd.Stdout = &b
+	c.cmd.Stderr = c.stderrTail
+	c.teeOutput()
+
+	err := c.startWait()
+	*output = b.Bytes()
+
+	return c.endState(err)
+}
+
+// startWait starts the cmd and waits for it to complete via the cmd's Executor
+//
+// The process is recorded once started, rather than executing the cmd via the Executor's
+// Run, such that it may be signaled while running, e.g. via Terminate from another goroutine.
+func (c *cmd) startWait() error {
+	if err := c.getExecutor().Start(c.cmd); err != nil {
+		return err
+	}
+
+	c.setProcess()
+	c.logStarted()
+
+	return c.wait()
+}
+
+// wait waits for the started exec.Cmd to complete
+//
+// a process which exits successfully succeeds, even if it's output remains held open by a
+// background process once OutputWaitDelay has elapsed, see also: exec.ErrWaitDelay
+func (c *cmd) wait() error {
+	err := c.getExecutor().Wait(c.cmd)
+	if errors.Is(err, exec.ErrWaitDelay) {
+		return nil
+	}
+
+	return err
+}
+
+// teeOutput tees the stdout and stderr of the exec.Cmd to the cmd's capture buffers and
+// line functions, if any
+func (c *cmd) teeOutput() {
+	c.captureOutput()
+	c.lineOutput()
+}
+
+// teesOutput returns whether the cmd tees it's output, see also: teeOutput
+func (c *cmd) teesOutput() bool {
+	return c.capture || c.onStdoutLine != nil || c.onStderrLine != nil
+}
+
 // clearStdOutStdErr will set the cmd stdout and stderr to nil
 func (c *cmd) clearStdOutStdErr() {
 	c.stdout = nil
@@ -321,11 +679,19 @@ func (c *cmd) clearStdOutStdErr() {
 }
 
 // endState sets the end state of the command after it's completion
+// any error returned is of type *Error
 func (c *cmd) endState(err error) error {
 	c.end = time.Now()
 	c.exitStatus(err)
 	c.complete = true
 
+	c.flushPrefixOutput()
+	c.flushLineOutput()
+
+	err = c.newError(err)
+	c.logComplete(err)
+	c.markDone()
+
 	return err
 }
 
@@ -333,82 +699,42 @@ func (c *cmd) endState(err error) error {
 // or return based on the dryrun value
 func (c *cmd) initAndContinue(command string, w ...io.Writer) bool {
 	c.buildExec(w...)
+	c.action = command
+
+	// retried attempts are only recorded once
+	if c.attempt <= 1 {
+		recordCmds(c)
+	}
 
 	if c.isDryRun() {
 		c.logCmdDryRun(command)
 		return false
 	}
 
-	if !c.silent {
-		c.LogCmd()
-	}
+	c.logCmd(command)
 
+	c.mu.Lock()
+	c.done = make(chan struct{})
+	c.proc = nil
+	c.process = nil
+	c.mu.Unlock()
+
+	c.started = false
 	c.start = time.Now()
 
 	return true
 }
 
-// isDryRun returns whether dryRun is set in the scope of the current cmd or globally
-func (c *cmd) isDryRun() bool {
-	return dryRun || c.dryRun
-}
-
-// exitStatus returns the exit status for the given cmd's error
-// if nil found, exit code will always be 0
-func (c *cmd) exitStatus(err error) {
-	if err == nil {
-		c.exitCode = 0
-		return
+// getAction returns the action key of the cmd's most recent action, defaulting to LoggerRunKey
+func (c *cmd) getAction() string {
+	if c.action == "" {
+		return log.LoggerRunKey
 	}
 
-	if e, ok := err.(exitStatus); ok {
-		c.exitCode = e.ExitStatus()
-	}
-
-	if e, ok := err.(*exec.ExitError); ok {
-		if ex, ok := e.Sys().(exitStatus); ok {
-			c.exitCode = ex.ExitStatus()
-		}
-	}
-
-	c.exitCode = 1
-}
-
-// exitStatus an interface implementing the ExitStatus method
-type exitStatus interface {
-	ExitStatus() int
+	return c.action
 }
 
-// logCmdDryRun logs the given cmd in the context of DryRun
-func (c *cmd) logCmdDryRun(s string) {
-	// save defaults
-	defKey := log.LoggerKey
-	defColor := log.LoggerColor
-
-	// override defaults
-	log.LoggerColor = log.LoggerDryRunColor
-	origSilent := c.unsetSilent()
-
-	if c.dryRunKey != "" {
-		log.LoggerKey = c.dryRunKey
-	} else {
-		log.LoggerKey = log.LoggerDryRunKey + " " + s
-		log.LoggerCols = log.LoggerDryRunCols
-	}
-
-	// log dry
-	c.LogCmd()
-
-	// reset defaults
-	log.LoggerKey = defKey
-	log.LoggerColor = defColor
-	c.silent = origSilent
-}
-
-// unsetSilent sets silent to false and returns the original value of c.silent
-func (c *cmd) unsetSilent() bool {
-	orig := c.silent
-	c.silent = false
-
-	return orig
+// isDryRun returns whether dryRun is set in the scope of the current cmd or globally
+func (c *cmd) isDryRun() bool {
+	return dryRun || c.dryRun
 }
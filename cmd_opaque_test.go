@@ -19,6 +19,7 @@ const (
 	sleep      string = "sleep"
 	foo        string = "foo"
 	five       string = "5"
+	tmp        string = "/tmp"
 	newLineStr string = "\n"
 )
 
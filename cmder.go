@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"syscall"
 	"time"
 
 	"github.com/scottames/cmder/pkg/log"
@@ -14,10 +15,46 @@ type Cmder interface {
 	// Args appends additional arguments to the given command
 	Args(...string) Cmder
 
+	// Attempts returns the history of each attempt of the last execution of the command
+	// via Run, Output or CombinedOutput, in order. See also: Retry
+	Attempts() []Attempt
+
+	// CancelSignal sets the signal sent to the process when the context passed via Ctx is
+	// done or Terminate is invoked. Defaults to DefaultCancelSignal (SIGTERM).
+	//
+	// If set without a GracePeriod the process is killed if it has not exited after
+	// DefaultGracePeriod.
+	// See also: GracePeriod, Terminate
+	CancelSignal(os.Signal) Cmder
+
+	// Capture sets the command's stdout and stderr to be captured, in addition to being
+	// written to the configured writers, see Out, Run and Start. The output of the last
+	// execution is available via Stdout, Stderr and Combined.
+	//
+	// Optionally a limit may be passed, in bytes, retaining only the last limit bytes of
+	// each of Stdout, Stderr and Combined to bound memory, defaults to unlimited.
+	//
+	// Note the process' stdout and stderr are pipes when captured, rather than the
+	// terminal, and are read separately, such that the order of output written to a shared
+	// writer is not guaranteed.
+	Capture(...int) Cmder
+
 	// Ctx can be used to pass context to the underlying exec command. The Run method will call
 	// exec.CommandContext with the given context
+	//
+	// By default the process is killed when the context is done. See also: CancelSignal,
+	// GracePeriod
 	Ctx(context.Context) Cmder
 
+	// ClearEnv clears the environment of the process, such that no environment variables
+	// are inherited from the calling process. Environment variables may then be added via
+	// Env or EnvMap. See also: InheritEnv
+	ClearEnv() Cmder
+
+	// Combined returns the interleaved stdout and stderr captured, in the order read, or
+	// nil if Capture is not set. See also: Capture
+	Combined() []byte
+
 	// CombinedOutput runs the command and returns its combined
 	// standard output and standard error.
 	CombinedOutput() ([]byte, error)
@@ -55,14 +92,115 @@ type Cmder interface {
 	// value in the slice for each duplicate key is used.
 	Env(...string) Cmder
 
+	// EnvFile loads the environment variables of the given env files into the environment
+	// of the process, in order, when the command is executed. See also: SetEnvFiles
+	//
+	// Env files contain lines of the form KEY=value, optionally prefixed with export.
+	// Values may be single quoted, preserved literally, or double quoted, where the escape
+	// sequences \n, \r, \t, \", \\ and \$ are supported. Both may span multiple lines.
+	// Blank lines and comments, lines or unquoted text starting with #, are ignored.
+	// References to variables, $VAR, ${VAR}, ${VAR:-default} or ${VAR-default}, within
+	// unquoted and double quoted values are expanded from the inherited environment and
+	// the variables previously loaded.
+	//
+	// Variables are resolved in order of precedence, lowest first: the inherited environment,
+	// the package level env files set via SetEnvFiles, the env files set via EnvFile and the
+	// variables added via Env, regardless of the order EnvFile and Env are called.
+	//
+	// Errors reading or parsing env files, including the file and line, are returned when
+	// the command is executed, including in DryRun mode.
+	EnvFile(...string) Cmder
+
+	// EnvMap adds the given environment variables to the environment of the process,
+	// in the manner of Env, in order of their keys
+	EnvMap(map[string]string) Cmder
+
+	// Environ returns the effective environment of the process, each entry of the form
+	// "key=value", where only the last value of any duplicate key is retained
+	//
+	// Env files are read on each call, variables of files which fail to load are omitted,
+	// the error is returned when the command is run.
+	Environ() []string
+
 	// ExitCode returns the exit code of the command. If Run has not been invoked
 	// zero will always be returned.
+	//
+	// If the command did not exit, e.g. it could not be found or was terminated
+	// by a signal, -1 is returned. See also: Status, Signal
 	ExitCode() int
 
+	// Executor sets the Executor used to execute the command, overriding the package
+	// level Executor set via SetExecutor. Defaults to executing the command with os/exec.
+	Executor(Executor) Cmder
+
+	// Expand sets the variable references in the arguments and working directory of the
+	// command to be expanded from the environment of the process when executed, as the
+	// command is not run by a shell. See also: ExpandStrict
+	//
+	// References of the form $VAR, ${VAR}, ${VAR:-default} or ${VAR-default} are supported,
+	// `$$` is expanded to a literal '$'. The environment includes the variables added via
+	// Env and loaded via EnvFile. Undefined variables are expanded to an empty string.
+	// Unlike a shell, the result of an expansion is never split into multiple arguments.
+	//
+	// Both the arguments and their expansion are logged. Errors, e.g. unsupported syntax
+	// such as $(...), are returned when the command is executed.
+	Expand() Cmder
+
+	// ExpandStrict sets the command to be expanded, as per Expand, returning an error
+	// wrapping ErrUndefinedVar when executed if any variable referenced without a default
+	// is undefined
+	ExpandStrict() Cmder
+
+	// Glob sets the glob patterns and brace expressions in the arguments of the command,
+	// other than the command itself, to be expanded when executed, as the command is not
+	// run by a shell, e.g. `gofmt -l ./**/*.go` or `rm build/{a,b}`
+	//
+	// Brace expressions are expanded first, then glob patterns are expanded to the paths
+	// they match relative to the working directory set via Dir, sorted per pattern.
+	// Patterns are matched per path element as per path.Match, while `**` matches zero or
+	// more directories. Hidden files are only matched by patterns starting with a '.'.
+	//
+	// Optionally a GlobMode may be passed selecting the behavior of patterns which match
+	// no files, defaults to GlobLiteral. Both the arguments and their expansion are logged.
+	Glob(...GlobMode) Cmder
+
+	// GracePeriod sets the time the process is given to exit after being sent the CancelSignal
+	// when the context passed via Ctx is done, prior to being killed. Defaults to
+	// DefaultGracePeriod if only CancelSignal is set.
+	//
+	// Built on exec.Cmd Cancel and WaitDelay.
+	// See also: CancelSignal, Terminate
+	GracePeriod(time.Duration) Cmder
+
 	// In connects the new process' stdin to the current process's stdin if no input provided.
 	// If input provided the input will be passed to the new process' stdin.
 	In(...byte) Cmder
 
+	// InheritEnv clears the environment of the process, inheriting only the environment
+	// variables of the calling process with the given keys, for hermetic execution.
+	// Keys may be patterns as per path.Match, e.g. "LC_*". Environment variables added
+	// prior to InheritEnv are discarded. See also: ClearEnv
+	InheritEnv(...string) Cmder
+
+	// OnStderrLine sets a function invoked with each line written to the process' stderr,
+	// as it is written, see also: OnStdoutLine
+	OnStderrLine(func(line string)) Cmder
+
+	// OnStdoutLine sets a function invoked with each line written to the process' stdout,
+	// as it is written, e.g. to parse progress or detect a server is ready
+	//
+	// Lines exclude the line ending, where a carriage return not followed by a newline, as
+	// written by progress updates, also ends a line. Lines longer than MaxLineLength are
+	// split. Any partial final line is passed once the process exits. The output is still
+	// written to the configured stdout, see Out, Run and Start, and returned by Output.
+	//
+	// The function is invoked from the goroutine copying the process' output and should
+	// not block, the functions set via OnStdoutLine and OnStderrLine may be invoked
+	// concurrently. Stdout and stderr are read separately when set, such that the order
+	// of output written to a shared writer, e.g. by CombinedOutput, is not guaranteed.
+	// Lines of intermediate stages of a Pipeline are not passed.
+	OnStdoutLine(func(line string)) Cmder
+
 	// Out connects the new process' stdout and optionally stderr to the given io.Writers
 	// Useful for writing to buffer or files
 	Out(stdout io.Writer, stderr ...io.Writer) Cmder
@@ -71,7 +209,9 @@ type Cmder interface {
 	//
 	// Kill causes the Process to exit immediately.
 	// Kill does not wait until the Process has actually exited.
-	// This only kills the Process itself, not any other processes it may have started.
+	// This only kills the Process itself, not any other processes it may have started,
+	// unless ProcessGroup has been set.
+	// See also: Terminate, ProcessGroup
 	Kill() error
 
 	// LogCmder will print the command that is to be executed.
@@ -86,19 +226,52 @@ type Cmder interface {
 	// Output invokes the os.exec Output method on the command
 	//
 	// Output runs the command and returns its stdout.
-	// Any returned error will usually be of type *exec.ExitError.
+	// Any returned error will be of type *Error, usually wrapping an *exec.ExitError.
 	Output() ([]byte, error)
 
+	// Pdeathsig sets the signal the process will receive if the calling process dies
+	//
+	// Only supported on Linux, ignored on other platforms. Note the signal is sent when the
+	// OS thread which started the process exits, see also: syscall.SysProcAttr
+	Pdeathsig(syscall.Signal) Cmder
+
 	// Pid returns the process id of the exited process or nil if the process has yet to exit.
 	// See also
 	// - Process
 	// - https://pkg.go.dev/os#Process
 	Pid() *int
 
+	// Prefix sets the command's stdout and stderr to be line buffered, with each line
+	// prefixed with the given label and a color from the log package palette, such that
+	// the output of concurrently running commands is not interleaved mid-line.
+	//
+	// Optionally a Mux may be passed, defaults to a package level Mux. Composes with Out and
+	// the io.Writers passed to Run and Start, which the prefixed lines are written to.
+	Prefix(label string, m ...*Mux) Cmder
+
 	// Process is the underlying process, once started.
 	// See also: https://pkg.go.dev/os#Process
 	Process() *os.Process
 
+	// ProcessGroup starts the process in it's own process group, such that Kill, Terminate
+	// and the context passed via Ctx being done signal the entire group, including any
+	// processes started by the command. Once the grace period of Terminate, or GracePeriod,
+	// has elapsed any processes remaining in the group are killed, even if the process
+	// itself has exited.
+	//
+	// Only supported on Unix platforms, ignored on other platforms.
+	ProcessGroup() Cmder
+
+	// Retry sets the RetryPolicy used to retry the command on failure
+	//
+	// Retry applies to Run, Output and CombinedOutput. Each attempt is logged with an
+	// attempt counter and the cmd's context is honored while waiting between attempts.
+	// Duration, ExitCode and Status reflect the final attempt, see Attempts for the
+	// history of every attempt.
+	//
+	// Input passed via In is replayed for each attempt.
+	Retry(RetryPolicy) Cmder
+
 	// Run invokes the os.exec Run method on the command
 	//
 	// Run calls exec.Run starting the specified command and waits for it to complete.
@@ -110,7 +283,7 @@ type Cmder interface {
 	//
 	// The returned error is nil if the command runs, has no problems copying stdin, stdout, and stderr, and exits with a zero exit status.
 	//
-	// If the command starts but does not complete successfully, the error is of type *ExitError. Other error types may be returned for other situations.
+	// If the command starts but does not complete successfully, the error is of type *Error wrapping an *exec.ExitError. Other errors may be wrapped for other situations.
 	//
 	// If the calling goroutine has locked the operating system thread with runtime.LockOSThread and modified any inheritable OS-level thread state (for example, Linux or Plan 9 name spaces), the new process will inherit the caller's thread state.
 	Run(...io.Writer) error
@@ -146,6 +319,17 @@ type Cmder interface {
 	// See also: RunFn
 	RunFnCmd(...io.Writer) func(args ...string) (Cmder, error)
 
+	// ShellString returns the command as a POSIX shell command suitable for copy-paste,
+	// e.g. `cd /tmp && FOO=bar echo 'hello world'`
+	//
+	// The working directory set via Dir is included as a `cd` and environment variables
+	// added via Env as `VAR=value` prefixes. Each word is quoted as required. As per Environ,
+	// env files are read on each call.
+	ShellString() string
+
+	// Signal returns the signal which terminated the command, if any
+	Signal() os.Signal
+
 	// Silent will set Run to not print the command prior to execution
 	Silent() Cmder
 
@@ -195,13 +379,39 @@ type Cmder interface {
 	// See also: StartFn, RunFnCmd, RunFn
 	StartFnCmd(...io.Writer) func(args ...string) (Cmder, error)
 
+	// Status returns the Status classifying how the command exited
+	//
+	// StatusPending is returned if the command has yet to complete.
+	// See also: ErrNotFound, ErrTimeout, ErrCanceled, ErrKilled
+	Status() Status
+
+	// Stderr returns the stderr captured, or nil if Capture is not set. See also: Capture
+	Stderr() []byte
+
+	// Stdout returns the stdout captured, or nil if Capture is not set. See also: Capture
+	Stdout() []byte
+
 	// String returns a human-readable description of the command
 	// from exec.Cmder. It is intended only for debugging.
 	// In particular, it is not suitable for use as input to a shell.
 	// The output of String may vary across Go releases.
-	// See also: DryRun
+	// See also: DryRun, ShellString
 	String() string
 
+	// Terminate gracefully terminates the started process by sending the CancelSignal
+	// (SIGTERM by default) and waiting up to the given grace period for it to exit
+	// before killing it.
+	//
+	// Terminate may be invoked from another goroutine while Run, Output or Wait is in
+	// progress. Otherwise Terminate does not release the resources associated with the
+	// process, Wait must still be invoked. If Wait is not running concurrently Terminate
+	// will always wait the full grace period.
+	Terminate(grace time.Duration) error
+
+	// Unsetenv removes the environment variables with the given keys from the environment
+	// of the process, including any added via Env. Keys may be patterns as per path.Match.
+	Unsetenv(...string) Cmder
+
 	// Wait invokes the os.exec Wait method on the command
 	//
 	// Wait waits for the command to exit and waits for any copying to
@@ -214,8 +424,8 @@ type Cmder interface {
 	// status.
 	//
 	// If the command fails to run or doesn't complete successfully, the
-	// error is of type *ExitError. Other error types may be
-	// returned for I/O problems.
+	// error is of type *Error wrapping an *exec.ExitError. Other errors
+	// may be wrapped for I/O problems.
 	//
 	// If any of c.Stdin, c.Stdout or c.Stderr are not an *os.File, Wait also waits
 	// for the respective I/O loop copying to or from the process to complete.
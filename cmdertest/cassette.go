@@ -0,0 +1,549 @@
+package cmdertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/scottames/cmder"
+)
+
+// CassetteEnv the environment variable used to select the CassetteMode of a Cassette
+// one of: record, replay (default) or passthrough
+const CassetteEnv = "CMDER_CASSETTE"
+
+// CassetteMode the mode in which a Cassette executes commands
+type CassetteMode string
+
+const (
+	// ModeRecord executes commands, recording each execution to the cassette file
+	ModeRecord CassetteMode = "record"
+
+	// ModeReplay replays executions from the cassette file without executing commands
+	ModeReplay CassetteMode = "replay"
+
+	// ModePassthrough executes commands without recording or replaying
+	ModePassthrough CassetteMode = "passthrough"
+)
+
+// ErrCassetteMismatch returned when a replayed command has no remaining recorded Interaction
+var ErrCassetteMismatch = errors.New("cmdertest: command not found in cassette")
+
+// Cassette a cmder.Executor which records the execution of real commands to a JSON
+// file, the cassette, and deterministically replays them, see also: NewCassette
+//
+// When replaying, each command is matched to the first Interaction with the same
+// arguments, working directory and environment which has not yet been replayed, in the
+// order recorded. Commands not found in the cassette fail to start with ErrCassetteMismatch,
+// describing the differences from any Interaction with the same arguments. The stdin of the
+// command is compared once read, Wait returns ErrCassetteMismatch if it differs.
+type Cassette struct {
+	fake       *Fake
+	mode       CassetteMode
+	mu         sync.Mutex
+	path       string
+	recordings map[*exec.Cmd]*recording
+	replayed   []bool
+	tape       tape
+}
+
+// Interaction a single recorded execution of a command
+type Interaction struct {
+	// Args the command and arguments
+	Args []string `json:"args"`
+
+	// Dir the working directory of the command
+	Dir string `json:"dir,omitempty"`
+
+	// Duration the time the command took to run
+	Duration time.Duration `json:"duration"`
+
+	// Env the environment variables of the command which differ from those of the
+	// recording process
+	Env []string `json:"env,omitempty"`
+
+	// ExitCode the exit code of the command, -1 if terminated by a signal
+	ExitCode int `json:"exitCode"`
+
+	// Signal the signal which terminated the command, if any
+	Signal syscall.Signal `json:"signal,omitempty"`
+
+	// Stderr the output written to the command's stderr
+	Stderr string `json:"stderr,omitempty"`
+
+	// Stdin the input read from the command's stdin, os.Stdin is never recorded
+	Stdin string `json:"stdin,omitempty"`
+
+	// Stdout the output written to the command's stdout
+	Stdout string `json:"stdout,omitempty"`
+}
+
+// tape the contents of a cassette file
+type tape struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// recording the state of a command being recorded or replayed
+type recording struct {
+	replay *Interaction
+	start  time.Time
+	stderr bytes.Buffer
+	stdin  bytes.Buffer
+	stdout bytes.Buffer
+}
+
+// NewCassette returns a new Cassette for the cassette file at the given path
+//
+// The CassetteMode is read from the CassetteEnv environment variable, defaulting to
+// ModeReplay, see also: Cassette.Mode
+func NewCassette(path string) *Cassette {
+	mode := CassetteMode(os.Getenv(CassetteEnv))
+	if mode == "" {
+		mode = ModeReplay
+	}
+
+	return &Cassette{
+		mode:       mode,
+		path:       path,
+		recordings: map[*exec.Cmd]*recording{},
+	}
+}
+
+// Mode sets the CassetteMode, overriding the CassetteEnv environment variable
+func (c *Cassette) Mode(m CassetteMode) *Cassette {
+	c.mode = m
+	return c
+}
+
+// Interactions returns each Interaction recorded or loaded for replay
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Interaction(nil), c.tape.Interactions...)
+}
+
+// Install loads the cassette and sets the Cassette as the cmder Executor for all
+// commands for the duration of the given test, restoring the default Executor once
+// complete. When recording, the cassette file is saved once the test is complete.
+//
+// Tests installing a Cassette should not be run in parallel.
+func (c *Cassette) Install(t testing.TB) *Cassette {
+	t.Helper()
+
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmder.SetExecutor(c)
+	t.Cleanup(func() {
+		cmder.SetExecutor(nil)
+
+		if err := c.Save(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return c
+}
+
+// Load prepares the Cassette for the configured CassetteMode, reading the cassette
+// file when replaying
+//
+// Load is called by Install, otherwise the cassette is loaded when the first command
+// is replayed.
+func (c *Cassette) Load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.load()
+}
+
+// load prepares the Cassette for the configured CassetteMode, see also: Load
+func (c *Cassette) load() error {
+	switch c.mode {
+	case ModeRecord, ModePassthrough:
+		return nil
+	case ModeReplay:
+	default:
+		return fmt.Errorf("cmdertest: unknown cassette mode %q, expected one of: %s, %s, %s",
+			c.mode, ModeRecord, ModeReplay, ModePassthrough)
+	}
+
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("cmdertest: reading cassette (record with %s=%s): %w", CassetteEnv, ModeRecord, err)
+	}
+
+	var t tape
+	if err := json.Unmarshal(b, &t); err != nil {
+		return fmt.Errorf("cmdertest: parsing cassette %s: %w", c.path, err)
+	}
+
+	c.tape = t
+	c.fake = NewFake()
+	c.replayed = make([]bool, len(t.Interactions))
+
+	return nil
+}
+
+// Save writes the recorded interactions to the cassette file when recording
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(c.tape, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path, append(b, '\n'), 0o644) //nolint:gosec,gomnd // not sensitive
+}
+
+// Output implements the cmder.Executor interface
+func (c *Cassette) Output(cmd *exec.Cmd) ([]byte, error) {
+	switch c.mode {
+	case ModeRecord, ModeReplay:
+	default:
+		return cmder.DefaultExecutor().Output(cmd)
+	}
+
+	if cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+
+	captureStderr := cmd.Stderr == nil
+	if captureStderr {
+		cmd.Stderr = &stderr
+	}
+
+	err := c.Run(cmd)
+
+	var (
+		exitErr *exec.ExitError
+		fakeErr *ExitError
+	)
+
+	switch {
+	case !captureStderr:
+	case errors.As(err, &exitErr):
+		exitErr.Stderr = stderr.Bytes()
+	case errors.As(err, &fakeErr):
+		fakeErr.Stderr = stderr.Bytes()
+	}
+
+	return stdout.Bytes(), err
+}
+
+// Run implements the cmder.Executor interface
+func (c *Cassette) Run(cmd *exec.Cmd) error {
+	if err := c.Start(cmd); err != nil {
+		return err
+	}
+
+	return c.Wait(cmd)
+}
+
+// Signal implements the cmder.Executor interface
+func (c *Cassette) Signal(cmd *exec.Cmd, sig os.Signal) error {
+	if c.mode == ModeReplay {
+		return c.replayer().Signal(cmd, sig)
+	}
+
+	return cmder.DefaultExecutor().Signal(cmd, sig)
+}
+
+// Start implements the cmder.Executor interface
+func (c *Cassette) Start(cmd *exec.Cmd) error {
+	switch c.mode {
+	case ModeReplay:
+		return c.replay(cmd)
+	case ModeRecord:
+	default:
+		return cmder.DefaultExecutor().Start(cmd)
+	}
+
+	r := &recording{start: time.Now()}
+
+	if cmd.Stdin != nil && cmd.Stdin != os.Stdin {
+		cmd.Stdin = io.TeeReader(cmd.Stdin, &r.stdin)
+	}
+
+	// commands sharing a writer for stdout and stderr are recorded as stdout
+	if sameWriter(cmd.Stdout, cmd.Stderr) {
+		cmd.Stdout = tee(cmd.Stdout, &r.stdout)
+		cmd.Stderr = cmd.Stdout
+	} else {
+		cmd.Stdout = tee(cmd.Stdout, &r.stdout)
+		cmd.Stderr = tee(cmd.Stderr, &r.stderr)
+	}
+
+	if err := cmder.DefaultExecutor().Start(cmd); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.recordings[cmd] = r
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Wait implements the cmder.Executor interface
+func (c *Cassette) Wait(cmd *exec.Cmd) error {
+	switch c.mode {
+	case ModeReplay:
+		return c.compareStdin(cmd, c.replayer().Wait(cmd))
+	case ModeRecord:
+	default:
+		return cmder.DefaultExecutor().Wait(cmd)
+	}
+
+	err := cmder.DefaultExecutor().Wait(cmd)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.recordings[cmd]
+	if !ok {
+		return err
+	}
+
+	delete(c.recordings, cmd)
+
+	c.tape.Interactions = append(c.tape.Interactions, Interaction{
+		Args:     append([]string(nil), cmd.Args...),
+		Dir:      cmd.Dir,
+		Duration: time.Since(r.start),
+		Env:      envDiff(cmd.Env, os.Environ()),
+		ExitCode: exitCode(err),
+		Signal:   exitSignal(err),
+		Stderr:   r.stderr.String(),
+		Stdin:    r.stdin.String(),
+		Stdout:   r.stdout.String(),
+	})
+
+	return err
+}
+
+// replayer returns the Fake replaying the loaded interactions
+func (c *Cassette) replayer() *Fake {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fake == nil {
+		return NewFake()
+	}
+
+	return c.fake
+}
+
+// replay starts the given command, replaying the first Interaction matching it which
+// has not yet been replayed, see also: match
+func (c *Cassette) replay(cmd *exec.Cmd) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fake == nil {
+		if err := c.load(); err != nil {
+			return err
+		}
+	}
+
+	i, err := c.match(cmd)
+	if err != nil {
+		return err
+	}
+
+	in := &c.tape.Interactions[i]
+	c.replayed[i] = true
+	c.fake.On(Exact(in.Args...)).
+		Stdout(in.Stdout).
+		Stderr(in.Stderr).
+		ExitCode(in.ExitCode).
+		Signal(in.Signal).
+		Times(1)
+
+	r := &recording{replay: in}
+
+	if cmd.Stdin != nil && cmd.Stdin != os.Stdin {
+		cmd.Stdin = io.TeeReader(cmd.Stdin, &r.stdin)
+	}
+
+	if err := c.fake.Start(cmd); err != nil {
+		return err
+	}
+
+	c.recordings[cmd] = r
+
+	return nil
+}
+
+// match returns the index of the first Interaction not yet replayed with the arguments,
+// working directory and environment of the given command, otherwise an error wrapping
+// ErrCassetteMismatch describing the differences from each Interaction with the same
+// arguments, or the remaining interactions if none
+func (c *Cassette) match(cmd *exec.Cmd) (int, error) {
+	env := envDiff(cmd.Env, os.Environ())
+	diffs := []string{}
+	remaining := [][]string{}
+
+	for i, in := range c.tape.Interactions {
+		if c.replayed[i] {
+			continue
+		}
+
+		if !Exact(in.Args...)(cmd.Args) {
+			remaining = append(remaining, in.Args)
+			continue
+		}
+
+		d := []string{}
+
+		if in.Dir != cmd.Dir {
+			d = append(d, fmt.Sprintf("dir %q, got %q", in.Dir, cmd.Dir))
+		}
+
+		if !equal(in.Env, env) {
+			d = append(d, fmt.Sprintf("env %q, got %q", in.Env, env))
+		}
+
+		if len(d) == 0 {
+			return i, nil
+		}
+
+		diffs = append(diffs, strings.Join(d, " and "))
+	}
+
+	if len(diffs) > 0 {
+		return -1, fmt.Errorf("%w: %v in cassette %s, recorded with %s",
+			ErrCassetteMismatch, cmd.Args, c.path, strings.Join(diffs, "; "))
+	}
+
+	return -1, fmt.Errorf("%w: %v in cassette %s, remaining interactions: %v",
+		ErrCassetteMismatch, cmd.Args, c.path, remaining)
+}
+
+// compareStdin returns an error wrapping ErrCassetteMismatch if the stdin read by the
+// given replayed command differs from the recorded Interaction, otherwise err
+func (c *Cassette) compareStdin(cmd *exec.Cmd, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.recordings[cmd]
+	if !ok {
+		return err
+	}
+
+	delete(c.recordings, cmd)
+
+	if got := r.stdin.String(); got != r.replay.Stdin {
+		return fmt.Errorf("%w: %v in cassette %s, recorded with stdin %q, got %q",
+			ErrCassetteMismatch, cmd.Args, c.path, r.replay.Stdin, got)
+	}
+
+	return err
+}
+
+// tee returns a writer duplicating writes to w, if not nil, and the given buffer
+func tee(w io.Writer, b *bytes.Buffer) io.Writer {
+	if w == nil {
+		return b
+	}
+
+	return io.MultiWriter(w, b)
+}
+
+// sameWriter returns whether the given writers are non-nil and equal
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+
+	return a != nil && a == b
+}
+
+// envDiff returns the entries of env not found in base
+func envDiff(env, base []string) []string {
+	set := make(map[string]bool, len(base))
+	for _, e := range base {
+		set[e] = true
+	}
+
+	diff := []string{}
+
+	for _, e := range env {
+		if !set[e] {
+			diff = append(diff, e)
+		}
+	}
+
+	return diff
+}
+
+// equal returns whether the given slices contain the same elements, nil and empty
+// slices are equal
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// exitCode returns the exit code represented by the given error
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// exitSignal returns the signal which terminated the command for the given error, if any
+func exitSignal(err error) syscall.Signal {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ProcessState == nil {
+		return 0
+	}
+
+	ws, ok := exitErr.Sys().(interface {
+		Signaled() bool
+		Signal() syscall.Signal
+	})
+	if ok && ws.Signaled() {
+		return ws.Signal()
+	}
+
+	return 0
+}
@@ -0,0 +1,137 @@
+package cmdertest_test
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/cmdertest"
+)
+
+const script = "cat; echo out; echo err >&2; exit 3"
+
+func Test_CassetteRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder := cmdertest.NewCassette(path).Mode(cmdertest.ModeRecord)
+	recorded := run(t, recorder)
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	interactions := recorder.Interactions()
+	if len(interactions) != 1 {
+		t.Fatalf("Expected 1 interaction. Got %d.", len(interactions))
+	}
+
+	assert.Equal(t, []string{"bash", "-c", script}, interactions[0].Args)
+	assert.Equal(t, []string{"FOO=bar"}, interactions[0].Env)
+	assert.Equal(t, "in\n", interactions[0].Stdin)
+	assert.Equal(t, "in\nout\n", interactions[0].Stdout)
+	assert.Equal(t, "err\n", interactions[0].Stderr)
+	assert.Equal(t, 3, interactions[0].ExitCode)
+
+	replayed := run(t, cmdertest.NewCassette(path).Mode(cmdertest.ModeReplay))
+	assert.Equal(t, recorded, replayed)
+}
+
+func Test_CassetteMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder := cmdertest.NewCassette(path).Mode(cmdertest.ModeRecord)
+	run(t, recorder)
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette := cmdertest.NewCassette(path).Mode(cmdertest.ModeReplay)
+	err := cmder.New("git", "push").Executor(cassette).Silent().Run()
+	assert.True(t, errors.Is(err, cmdertest.ErrCassetteMismatch), "Expected ErrCassetteMismatch. Got %v.", err)
+	assert.Contains(t, err.Error(), "[git push]")
+
+	// each interaction is only replayed once
+	run(t, cassette)
+	_, err = cmder.New("bash", "-c", script).Executor(cassette).Silent().Output()
+	assert.True(t, errors.Is(err, cmdertest.ErrCassetteMismatch), "Expected ErrCassetteMismatch. Got %v.", err)
+}
+
+func Test_CassetteMismatchDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder := cmdertest.NewCassette(path).Mode(cmdertest.ModeRecord)
+	run(t, recorder)
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cmd      cmder.Cmder
+		contains string
+	}{
+		{"dir", cmder.New("bash", "-c", script).Dir(t.TempDir()).Env("FOO=bar").In([]byte("in\n")...), "dir"},
+		{"env", cmder.New("bash", "-c", script).Env("FOO=baz").In([]byte("in\n")...), `env ["FOO=bar"], got ["FOO=baz"]`},
+		{"stdin", cmder.New("bash", "-c", script).Env("FOO=bar").In([]byte("other\n")...), `stdin "in\n", got "other\n"`},
+	}
+
+	for _, tt := range tests {
+		cassette := cmdertest.NewCassette(path).Mode(cmdertest.ModeReplay)
+		err := tt.cmd.Executor(cassette).Silent().Run()
+
+		assert.True(t, errors.Is(err, cmdertest.ErrCassetteMismatch), "%s: Expected ErrCassetteMismatch. Got %v.", tt.name, err)
+		assert.Contains(t, err.Error(), tt.contains, tt.name)
+	}
+}
+
+func Test_CassetteSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder := cmdertest.NewCassette(path).Mode(cmdertest.ModeRecord)
+	_ = cmder.New("bash", "-c", "kill -TERM $$").Executor(recorder).Silent().Run()
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	interactions := recorder.Interactions()
+	if len(interactions) != 1 {
+		t.Fatalf("Expected 1 interaction. Got %d.", len(interactions))
+	}
+
+	assert.Equal(t, syscall.SIGTERM, interactions[0].Signal)
+	assert.Equal(t, -1, interactions[0].ExitCode)
+
+	c := cmder.New("bash", "-c", "kill -TERM $$").Executor(cmdertest.NewCassette(path).Mode(cmdertest.ModeReplay)).Silent()
+	err := c.Run()
+
+	assert.Error(t, err)
+	assert.Equal(t, syscall.SIGTERM, c.Signal())
+	assert.Equal(t, -1, c.ExitCode())
+}
+
+func Test_CassetteMissing(t *testing.T) {
+	cassette := cmdertest.NewCassette(filepath.Join(t.TempDir(), "missing.json")).Mode(cmdertest.ModeReplay)
+	assert.Error(t, cassette.Load())
+}
+
+// run runs the test script with the given Cassette returning the stdout and exit code
+func run(t *testing.T, cassette *cmdertest.Cassette) []interface{} {
+	t.Helper()
+
+	c := cmder.New("bash", "-c", script).Env("FOO=bar").In([]byte("in\n")...).Executor(cassette).Silent()
+	out, err := c.Output()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %v.", err)
+	}
+
+	return []interface{}{string(out), c.ExitCode(), c.Status()}
+}
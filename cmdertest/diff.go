@@ -0,0 +1,100 @@
+package cmdertest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells the maximum size of the table used to diff two strings, the product of
+// their line counts, beyond which only the first differing line is reported
+const maxDiffCells = 1 << 20
+
+// diff returns a line based diff of the given strings, lines only in want are
+// prefixed with "-", lines only in got are prefixed with "+"
+//
+// The first differing line is reported instead if the strings are too large to diff,
+// see also: maxDiffCells
+func diff(want, got string) string {
+	a := strings.SplitAfter(want, "\n")
+	b := strings.SplitAfter(got, "\n")
+
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return firstDiff(a, b)
+	}
+
+	// lcs[i][j] the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			writeLine(&sb, " ", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			writeLine(&sb, "+", b[j])
+			j++
+		default:
+			writeLine(&sb, "-", a[i])
+			i++
+		}
+	}
+
+	return sb.String()
+}
+
+// firstDiff returns the first differing line of the given lines, prefixed with the
+// line number
+func firstDiff(a, b []string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "first difference at line %d, too large to diff:\n", i+1)
+
+	if i < len(a) {
+		writeLine(&sb, "-", a[i])
+	}
+
+	if i < len(b) {
+		writeLine(&sb, "+", b[i])
+	}
+
+	return sb.String()
+}
+
+// writeLine writes the given line with the given prefix, terminated by a newline
+func writeLine(sb *strings.Builder, prefix, line string) {
+	if line == "" {
+		return
+	}
+
+	sb.WriteString(prefix)
+	sb.WriteString(line)
+
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,273 @@
+package cmdertest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottames/cmder"
+)
+
+// UpdateEnv the environment variable used to update golden files with the actual output
+// when set to true, see also: Expectation.StdoutGolden
+const UpdateEnv = "CMDER_UPDATE"
+
+// Expectation the result of a command run by Expect, with chainable assertions
+//
+// Each failed assertion is reported via testing.TB Errorf, allowing every assertion
+// of the chain to be reported.
+type Expectation struct {
+	cmd    cmder.Cmder
+	err    error
+	stderr bytes.Buffer
+	stdout bytes.Buffer
+	t      testing.TB
+}
+
+// Expect runs the given command, capturing its stdout and stderr, and returns an
+// Expectation for asserting on the result
+//
+//	cmdertest.Expect(t, cmder.New("go", "version")).ExitCode(0).StdoutContains("go1.")
+func Expect(t testing.TB, c cmder.Cmder) *Expectation {
+	t.Helper()
+
+	e := &Expectation{cmd: c, t: t}
+	e.err = c.Out(&e.stdout, &e.stderr).Run()
+
+	return e
+}
+
+// Cmd returns the command which was run
+func (e *Expectation) Cmd() cmder.Cmder {
+	return e.cmd
+}
+
+// Err returns the error returned by the command, if any
+func (e *Expectation) Err() error {
+	return e.err
+}
+
+// Stderr returns the captured stderr of the command
+func (e *Expectation) Stderr() string {
+	return e.stderr.String()
+}
+
+// Stdout returns the captured stdout of the command
+func (e *Expectation) Stdout() string {
+	return e.stdout.String()
+}
+
+// DurationUnder asserts the command completed in less than the given duration
+func (e *Expectation) DurationUnder(d time.Duration) *Expectation {
+	e.t.Helper()
+
+	if actual := e.cmd.Duration(); actual >= d {
+		e.errorf("expected duration under %s, got %s", d, actual)
+	}
+
+	return e
+}
+
+// ExitCode asserts the exit code of the command
+func (e *Expectation) ExitCode(code int) *Expectation {
+	e.t.Helper()
+
+	if actual := e.cmd.ExitCode(); actual != code {
+		e.errorf("expected exit code %d, got %d (%v)", code, actual, e.err)
+	}
+
+	return e
+}
+
+// Status asserts the Status of the command
+func (e *Expectation) Status(status cmder.Status) *Expectation {
+	e.t.Helper()
+
+	if actual := e.cmd.Status(); actual != status {
+		e.errorf("expected status %s, got %s (%v)", status, actual, e.err)
+	}
+
+	return e
+}
+
+// Success asserts the command completed successfully
+func (e *Expectation) Success() *Expectation {
+	e.t.Helper()
+
+	if e.err != nil {
+		e.errorf("expected success, got %v", e.err)
+	}
+
+	return e
+}
+
+// StderrContains asserts the stderr of the command contains the given string
+func (e *Expectation) StderrContains(s string) *Expectation {
+	e.t.Helper()
+	e.contains("stderr", e.Stderr(), s)
+
+	return e
+}
+
+// StderrEmpty asserts the command wrote nothing to stderr
+func (e *Expectation) StderrEmpty() *Expectation {
+	e.t.Helper()
+	e.empty("stderr", e.Stderr())
+
+	return e
+}
+
+// StderrEqual asserts the stderr of the command equals the given string
+func (e *Expectation) StderrEqual(s string) *Expectation {
+	e.t.Helper()
+	e.equal("stderr", s, e.Stderr())
+
+	return e
+}
+
+// StderrGolden asserts the stderr of the command equals the contents of the given
+// golden file, see also: StdoutGolden
+func (e *Expectation) StderrGolden(path string) *Expectation {
+	e.t.Helper()
+	e.golden("stderr", path, e.Stderr())
+
+	return e
+}
+
+// StderrMatches asserts the stderr of the command matches the given regular expression
+func (e *Expectation) StderrMatches(expr string) *Expectation {
+	e.t.Helper()
+	e.matches("stderr", e.Stderr(), expr)
+
+	return e
+}
+
+// StdoutContains asserts the stdout of the command contains the given string
+func (e *Expectation) StdoutContains(s string) *Expectation {
+	e.t.Helper()
+	e.contains("stdout", e.Stdout(), s)
+
+	return e
+}
+
+// StdoutEmpty asserts the command wrote nothing to stdout
+func (e *Expectation) StdoutEmpty() *Expectation {
+	e.t.Helper()
+	e.empty("stdout", e.Stdout())
+
+	return e
+}
+
+// StdoutEqual asserts the stdout of the command equals the given string
+func (e *Expectation) StdoutEqual(s string) *Expectation {
+	e.t.Helper()
+	e.equal("stdout", s, e.Stdout())
+
+	return e
+}
+
+// StdoutGolden asserts the stdout of the command equals the contents of the given
+// golden file, e.g. testdata/version.golden
+//
+// Run the tests with CMDER_UPDATE=true to write the golden file with the actual stdout.
+func (e *Expectation) StdoutGolden(path string) *Expectation {
+	e.t.Helper()
+	e.golden("stdout", path, e.Stdout())
+
+	return e
+}
+
+// StdoutMatches asserts the stdout of the command matches the given regular expression
+func (e *Expectation) StdoutMatches(expr string) *Expectation {
+	e.t.Helper()
+	e.matches("stdout", e.Stdout(), expr)
+
+	return e
+}
+
+// contains asserts the given output contains the given string
+func (e *Expectation) contains(name, output, s string) {
+	e.t.Helper()
+
+	if !strings.Contains(output, s) {
+		e.errorf("expected %s to contain %q, got:\n%s", name, s, output)
+	}
+}
+
+// empty asserts the given output is empty
+func (e *Expectation) empty(name, output string) {
+	e.t.Helper()
+
+	if output != "" {
+		e.errorf("expected %s to be empty, got:\n%s", name, output)
+	}
+}
+
+// equal asserts the given output equals the expected string
+func (e *Expectation) equal(name, expected, output string) {
+	e.t.Helper()
+
+	if output != expected {
+		e.errorf("%s differs (-expected +actual):\n%s", name, diff(expected, output))
+	}
+}
+
+// golden asserts the given output equals the contents of the given golden file
+// writing the golden file instead when updating, see also: UpdateEnv
+func (e *Expectation) golden(name, path, output string) {
+	e.t.Helper()
+
+	if update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gomnd // directory permissions
+			e.t.Fatal(err)
+		}
+
+		//nolint:gosec,gomnd // not sensitive
+		if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+			e.t.Fatal(err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		e.errorf("reading golden file (update with %s=true): %v", UpdateEnv, err)
+		return
+	}
+
+	if output != string(expected) {
+		e.errorf("%s differs from golden file %s (-expected +actual):\n%s", name, path, diff(string(expected), output))
+	}
+}
+
+// matches asserts the given output matches the given regular expression
+func (e *Expectation) matches(name, output, expr string) {
+	e.t.Helper()
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+
+	if !re.MatchString(output) {
+		e.errorf("expected %s to match %q, got:\n%s", name, expr, output)
+	}
+}
+
+// errorf reports a failed assertion, prefixed with the command
+func (e *Expectation) errorf(format string, v ...interface{}) {
+	e.t.Helper()
+	e.t.Errorf("%s: "+format, append([]interface{}{e.cmd}, v...)...)
+}
+
+// update returns whether golden files should be updated
+func update() bool {
+	ok, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return ok
+}
@@ -0,0 +1,112 @@
+package cmdertest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/cmdertest"
+)
+
+// recorder implements testing.TB recording failed assertions rather than failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, v ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, v...))
+}
+
+func Test_Expect(t *testing.T) {
+	cmdertest.Expect(t, cmder.New("bash", "-c", "echo foo bar").Silent()).
+		Success().
+		ExitCode(0).
+		Status(cmder.StatusSuccess).
+		StdoutEqual("foo bar\n").
+		StdoutContains("foo").
+		StdoutMatches(`(?m)^foo \w+$`).
+		StderrEmpty().
+		DurationUnder(5 * time.Second)
+}
+
+func Test_ExpectFailures(t *testing.T) {
+	r := &recorder{TB: t}
+
+	cmdertest.Expect(r, cmder.New("bash", "-c", "printf 'a\nb\nc\n'; echo oops >&2; exit 1").Silent()).
+		ExitCode(0).
+		StdoutEqual("a\nx\nc\n").
+		StdoutContains("z").
+		StderrEmpty().
+		StderrContains("oops")
+
+	if len(r.errors) != 4 {
+		t.Fatalf("Expected 4 failed assertions. Got %d: %v", len(r.errors), r.errors)
+	}
+
+	assert.Contains(t, r.errors[0], "expected exit code 0, got 1")
+	assert.Contains(t, r.errors[1], " a\n-x\n+b\n c\n")
+	assert.Contains(t, r.errors[2], `expected stdout to contain "z"`)
+	assert.Contains(t, r.errors[3], "expected stderr to be empty, got:\noops")
+}
+
+func Test_ExpectGolden(t *testing.T) {
+	t.Setenv(cmdertest.UpdateEnv, "")
+
+	golden := filepath.Join(t.TempDir(), "echo.golden")
+
+	if err := os.WriteFile(golden, []byte("foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdertest.Expect(t, cmder.New("echo", "foo").Silent()).StdoutGolden(golden)
+
+	r := &recorder{TB: t}
+	cmdertest.Expect(r, cmder.New("echo", "bar").Silent()).StdoutGolden(golden)
+
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 failed assertion. Got %d: %v", len(r.errors), r.errors)
+	}
+
+	assert.Contains(t, r.errors[0], "-foo\n+bar\n")
+}
+
+func Test_ExpectLargeDiff(t *testing.T) {
+	lines := make([]string, 2000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i + 1)
+	}
+
+	lines[1499] = "x"
+
+	r := &recorder{TB: t}
+	cmdertest.Expect(r, cmder.New("seq", "2000").Silent()).StdoutEqual(strings.Join(lines, "\n") + "\n")
+
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 failed assertion. Got %d: %v", len(r.errors), r.errors)
+	}
+
+	assert.Contains(t, r.errors[0], "first difference at line 1500, too large to diff:\n-x\n+1500\n")
+}
+
+func Test_ExpectGoldenUpdate(t *testing.T) {
+	t.Setenv(cmdertest.UpdateEnv, "true")
+
+	golden := filepath.Join(t.TempDir(), "testdata", "echo.golden")
+
+	cmdertest.Expect(t, cmder.New("echo", "foo").Silent()).StdoutGolden(golden)
+
+	b, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "foo\n", string(b))
+}
@@ -0,0 +1,397 @@
+// Package cmdertest provides utilities for testing code which executes commands with cmder
+package cmdertest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/scottames/cmder"
+)
+
+// ErrUnmatched returned when a command executed by a Fake does not match any Response
+var ErrUnmatched = errors.New("cmdertest: no response matches command")
+
+// Fake a cmder.Executor which fakes the execution of commands, returning canned
+// responses and recording each invocation, see also: NewFake
+//
+// Commands are matched against each Response in the order registered via On,
+// the first matching Response is used. Commands which do not match any Response
+// fail to start with ErrUnmatched.
+type Fake struct {
+	calls     []Call
+	mu        sync.Mutex
+	procs     map[*exec.Cmd]*process
+	responses []*Response
+}
+
+// Call a single invocation of a command executed by a Fake
+type Call struct {
+	// Args the command and arguments
+	Args []string
+
+	// Dir the working directory of the command
+	Dir string
+
+	// Env the environment of the command
+	Env []string
+
+	// Signals the signals sent to the command, e.g. via Kill
+	Signals []os.Signal
+
+	// Stdin the input read from the command's stdin, os.Stdin is never read
+	Stdin []byte
+}
+
+// Response the canned response of a faked command, see also: Fake.On
+type Response struct {
+	delay    time.Duration
+	err      error
+	exitCode int
+	match    Matcher
+	signal   syscall.Signal
+	stderr   string
+	stdout   string
+	times    int
+	used     int
+}
+
+// ExitError the error returned by a faked command which exits non-zero or is signaled
+//
+// ExitError implements the ExitStatus method, used by cmder to determine the exit code.
+type ExitError struct {
+	// Code the exit code of the command, -1 if signaled
+	Code int
+
+	// Sig the signal which terminated the command, if any
+	Sig syscall.Signal
+
+	// Stderr the stderr of the command if not otherwise collected by Output
+	Stderr []byte
+}
+
+// process the state of a started command
+type process struct {
+	call   int
+	done   chan struct{}
+	err    error
+	signal chan os.Signal
+}
+
+// NewFake returns a new Fake
+func NewFake() *Fake {
+	return &Fake{procs: map[*exec.Cmd]*process{}}
+}
+
+// Install sets the Fake as the cmder Executor for all commands for the duration of the
+// given test, restoring the default Executor once complete
+//
+// Tests installing a Fake should not be run in parallel, see also: cmder.Cmder.Executor
+// to set the Fake for a single command.
+func (f *Fake) Install(t testing.TB) *Fake {
+	t.Helper()
+
+	cmder.SetExecutor(f)
+	t.Cleanup(func() { cmder.SetExecutor(nil) })
+
+	return f
+}
+
+// On registers and returns a new Response for commands matching the given Matcher
+//
+// The Response succeeds with no output unless otherwise configured.
+func (f *Fake) On(m Matcher) *Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := &Response{match: m}
+	f.responses = append(f.responses, r)
+
+	return r
+}
+
+// Calls returns each invocation of a command executed by the Fake in order
+func (f *Fake) Calls() []Call {
+	return f.CallsMatching(Any())
+}
+
+// CallsMatching returns each invocation of a command matching the given Matcher in order
+func (f *Fake) CallsMatching(m Matcher) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := []Call{}
+
+	for _, c := range f.calls {
+		if m(c.Args) {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// Output implements the cmder.Executor interface
+func (f *Fake) Output(c *exec.Cmd) ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	c.Stdout = &stdout
+
+	captureStderr := c.Stderr == nil
+	if captureStderr {
+		c.Stderr = &stderr
+	}
+
+	err := f.Run(c)
+
+	var exitErr *ExitError
+	if captureStderr && errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+
+	return stdout.Bytes(), err
+}
+
+// Run implements the cmder.Executor interface
+func (f *Fake) Run(c *exec.Cmd) error {
+	if err := f.Start(c); err != nil {
+		return err
+	}
+
+	return f.Wait(c)
+}
+
+// Signal implements the cmder.Executor interface
+//
+// The signal is recorded and the command, if still running, exits as terminated
+// by the given signal.
+func (f *Fake) Signal(c *exec.Cmd, sig os.Signal) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.procs[c]
+	if !ok {
+		return os.ErrProcessDone
+	}
+
+	f.calls[p.call].Signals = append(f.calls[p.call].Signals, sig)
+
+	select {
+	case <-p.done:
+		return os.ErrProcessDone
+	default:
+	}
+
+	select {
+	case p.signal <- sig:
+	default:
+	}
+
+	return nil
+}
+
+// Start implements the cmder.Executor interface
+func (f *Fake) Start(c *exec.Cmd) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.procs[c]; ok {
+		return errors.New("exec: already started")
+	}
+
+	call := Call{
+		Args: append([]string(nil), c.Args...),
+		Dir:  c.Dir,
+		Env:  append([]string(nil), c.Env...),
+	}
+	f.calls = append(f.calls, call)
+
+	r := f.response(call.Args)
+	if r == nil {
+		return fmt.Errorf("%w: %v", ErrUnmatched, call.Args)
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+
+	p := &process{
+		call:   len(f.calls) - 1,
+		done:   make(chan struct{}),
+		signal: make(chan os.Signal, 1),
+	}
+	f.procs[c] = p
+
+	go f.run(c, p, r)
+
+	return nil
+}
+
+// Wait implements the cmder.Executor interface
+func (f *Fake) Wait(c *exec.Cmd) error {
+	f.mu.Lock()
+	p, ok := f.procs[c]
+	f.mu.Unlock()
+
+	if !ok {
+		return errors.New("exec: not started")
+	}
+
+	<-p.done
+
+	f.mu.Lock()
+	delete(f.procs, c)
+	f.mu.Unlock()
+
+	return p.err
+}
+
+// response returns the first Response matching the given arguments, if any,
+// which has not been used the number of times set via Times
+func (f *Fake) response(args []string) *Response {
+	for _, r := range f.responses {
+		if r.times > 0 && r.used >= r.times {
+			continue
+		}
+
+		if r.match(args) {
+			r.used++
+			return r
+		}
+	}
+
+	return nil
+}
+
+// run fakes the execution of the given started command with the given Response
+func (f *Fake) run(c *exec.Cmd, p *process, r *Response) {
+	defer close(p.done)
+
+	if c.Stdin != nil && c.Stdin != os.Stdin {
+		stdin, _ := io.ReadAll(c.Stdin)
+
+		f.mu.Lock()
+		f.calls[p.call].Stdin = stdin
+		f.mu.Unlock()
+	}
+
+	if r.delay > 0 {
+		timer := time.NewTimer(r.delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case sig := <-p.signal:
+			p.err = signaledError(sig)
+			return
+		}
+	}
+
+	if c.Stdout != nil {
+		_, _ = io.WriteString(c.Stdout, r.stdout)
+	}
+
+	if c.Stderr != nil {
+		_, _ = io.WriteString(c.Stderr, r.stderr)
+	}
+
+	switch {
+	case r.signal != 0:
+		p.err = signaledError(r.signal)
+	case r.exitCode != 0:
+		p.err = &ExitError{Code: r.exitCode}
+	}
+}
+
+// Delay sets the time the command takes to run before responding
+//
+// The command may be interrupted by a signal, e.g. Kill or Terminate, during the delay.
+func (r *Response) Delay(d time.Duration) *Response {
+	r.delay = d
+	return r
+}
+
+// Err sets the error returned when starting the command, e.g. exec.ErrNotFound
+func (r *Response) Err(err error) *Response {
+	r.err = err
+	return r
+}
+
+// ExitCode sets the exit code of the command, defaults to 0
+func (r *Response) ExitCode(code int) *Response {
+	r.exitCode = code
+	return r
+}
+
+// Signal sets the signal terminating the command once its output is written, overriding
+// ExitCode
+func (r *Response) Signal(sig syscall.Signal) *Response {
+	r.signal = sig
+	return r
+}
+
+// Times sets the number of times the Response may be used, defaults to unlimited
+//
+// Once used the given number of times, commands are matched against the Responses
+// registered after it.
+func (r *Response) Times(n int) *Response {
+	r.times = n
+	return r
+}
+
+// Stderr sets the output written to the command's stderr
+func (r *Response) Stderr(s string) *Response {
+	r.stderr = s
+	return r
+}
+
+// Stdout sets the output written to the command's stdout
+func (r *Response) Stdout(s string) *Response {
+	r.stdout = s
+	return r
+}
+
+// Error implements the error interface
+func (e *ExitError) Error() string {
+	if e.Sig != 0 {
+		return "signal: " + e.Sig.String()
+	}
+
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitStatus returns the exit code of the command
+func (e *ExitError) ExitStatus() int {
+	return e.Code
+}
+
+// Signal returns the signal which terminated the command
+func (e *ExitError) Signal() syscall.Signal {
+	return e.Sig
+}
+
+// Signaled returns whether the command was terminated by a signal
+func (e *ExitError) Signaled() bool {
+	return e.Sig != 0
+}
+
+// signaledError returns a new ExitError for a command terminated by the given signal
+func signaledError(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		s = syscall.SIGKILL
+	}
+
+	return &ExitError{Code: -1, Sig: s}
+}
@@ -0,0 +1,132 @@
+package cmdertest_test
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/cmdertest"
+)
+
+func Test_FakeOutput(t *testing.T) {
+	fake := cmdertest.NewFake().Install(t)
+	fake.On(cmdertest.Exact("git", "rev-parse", "HEAD")).Stdout("abc123\n")
+	fake.On(cmdertest.HasPrefix("git")).Stdout("other\n")
+
+	out, err := cmder.New("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "abc123\n", string(out))
+
+	out, err = cmder.New("git", "status").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "other\n", string(out))
+
+	expected := [][]string{{"git", "rev-parse", "HEAD"}, {"git", "status"}}
+	actual := [][]string{}
+
+	for _, c := range fake.Calls() {
+		actual = append(actual, c.Args)
+	}
+
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_FakeCombinedOutput(t *testing.T) {
+	fake := cmdertest.NewFake()
+	fake.On(cmdertest.Regexp(`^docker (build|push)\b`)).Stdout("out\n").Stderr("err\n").ExitCode(2)
+
+	out, err := cmder.New("docker", "build", ".").Executor(fake).Silent().CombinedOutput()
+
+	var cmdErr *cmder.Error
+	assert.True(t, errors.As(err, &cmdErr), "Expected *cmder.Error. Got %T.", err)
+	assert.Equal(t, 2, cmdErr.ExitCode)
+	assert.Equal(t, cmder.StatusFailed, cmdErr.Status)
+	assert.Equal(t, "out\nerr\n", string(cmdErr.Stderr))
+	assert.Equal(t, "out\nerr\n", string(out))
+}
+
+func Test_FakeRecordsCall(t *testing.T) {
+	fake := cmdertest.NewFake()
+	fake.On(cmdertest.Any())
+
+	err := cmder.New("go", "test").Dir("/tmp").Env("FOO=bar").In([]byte("input")...).
+		Executor(fake).Silent().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.CallsMatching(cmdertest.HasPrefix("go"))
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 call. Got %d.", len(calls))
+	}
+
+	assert.Equal(t, "/tmp", calls[0].Dir)
+	assert.Contains(t, calls[0].Env, "FOO=bar")
+	assert.Equal(t, "input", string(calls[0].Stdin))
+}
+
+func Test_FakeUnmatched(t *testing.T) {
+	fake := cmdertest.NewFake()
+
+	err := cmder.New("make").Executor(fake).Silent().Run()
+	assert.True(t, errors.Is(err, cmdertest.ErrUnmatched), "Expected ErrUnmatched. Got %v.", err)
+}
+
+func Test_FakeNotFound(t *testing.T) {
+	fake := cmdertest.NewFake()
+	fake.On(cmdertest.Exact("terraform")).Err(exec.ErrNotFound)
+
+	c := cmder.New("terraform").Executor(fake).Silent()
+	err := c.Run()
+
+	assert.True(t, errors.Is(err, cmder.ErrNotFound), "Expected ErrNotFound. Got %v.", err)
+	assert.Equal(t, cmder.StatusNotFound, c.Status())
+}
+
+func Test_FakeStartKill(t *testing.T) {
+	fake := cmdertest.NewFake()
+	fake.On(cmdertest.Exact("sleep", "5")).Delay(5 * time.Second)
+
+	c := cmder.New("sleep", "5").Executor(fake).Silent()
+
+	err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.Kill()
+	err = c.Wait()
+
+	assert.True(t, errors.Is(err, cmder.ErrKilled), "Expected ErrKilled. Got %v.", err)
+	assert.Equal(t, -1, c.ExitCode())
+	assert.Len(t, fake.Calls()[0].Signals, 1)
+}
+
+func Test_FakePipe(t *testing.T) {
+	fake := cmdertest.NewFake()
+	fake.On(cmdertest.HasPrefix("cat")).Stdout("a\nb\n")
+	fake.On(cmdertest.HasPrefix("wc")).Stdout("2\n")
+
+	out, err := cmder.Pipe(
+		cmder.New("cat", "file").Executor(fake),
+		cmder.New("wc", "-l").Executor(fake),
+	).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "2\n", string(out))
+	assert.Equal(t, "a\nb\n", string(fake.CallsMatching(cmdertest.HasPrefix("wc"))[0].Stdin))
+}
@@ -0,0 +1,29 @@
+package cmdertest
+
+import "github.com/scottames/cmder"
+
+// Matcher reports whether the given command and arguments match, see also: cmder.Matcher
+type Matcher = cmder.Matcher
+
+// Any returns a Matcher matching any command, see also: cmder.Any
+func Any() Matcher {
+	return cmder.Any()
+}
+
+// Exact returns a Matcher matching the given command and arguments exactly,
+// see also: cmder.Exact
+func Exact(args ...string) Matcher {
+	return cmder.Exact(args...)
+}
+
+// HasPrefix returns a Matcher matching commands starting with the given command and
+// arguments, see also: cmder.HasPrefix
+func HasPrefix(args ...string) Matcher {
+	return cmder.HasPrefix(args...)
+}
+
+// Regexp returns a Matcher matching the given regular expression against the command
+// and arguments joined by a single space, see also: cmder.Regexp
+func Regexp(expr string) Matcher {
+	return cmder.Regexp(expr)
+}
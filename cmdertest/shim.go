@@ -0,0 +1,269 @@
+package cmdertest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scottames/cmder"
+)
+
+// shimScript the POSIX shell script of each shim executable
+//
+// Each invocation is recorded to a new numbered directory of the calls directory,
+// created with mkdir which is atomic, such that concurrent invocations are ordered.
+// stdin is only recorded when set explicitly, i.e. a pipe or regular file other than
+// the stdin of the test process, identified by it's inode, which may never be closed.
+// Otherwise stdin is replaced with /dev/null, such that the shim never blocks on it.
+const shimScript = `#!/bin/sh
+calls=%[1]s
+resp=%[2]s
+inherited=%[4]s
+n=0
+while ! mkdir "$calls/$n" 2>/dev/null; do
+  n=$((n+1))
+done
+printf '%%s\0' %[3]s "$@" > "$calls/$n/argv"
+pwd > "$calls/$n/cwd"
+stdin=$(ls -iL /dev/stdin 2>/dev/null | awk '{print $1}')
+if [ -n "$inherited" ] && [ "$stdin" = "$inherited" ]; then
+  exec </dev/null
+elif [ -p /dev/stdin ] || [ -f /dev/stdin ]; then
+  cat > "$calls/$n/stdin"
+else
+  exec </dev/null
+fi
+if [ -f "$resp/script" ]; then
+  . "$resp/script"
+fi
+if [ -f "$resp/stdout" ]; then
+  cat "$resp/stdout"
+fi
+if [ -f "$resp/stderr" ]; then
+  cat "$resp/stderr" >&2
+fi
+if [ -f "$resp/exit" ]; then
+  exit "$(cat "$resp/exit")"
+fi
+exit 0
+`
+
+// Shims a directory of shim executables which record each invocation, see also: NewShims
+//
+// Prepending the directory to the PATH of a command (see Apply) causes any child
+// process spawned by the command to execute the shims, e.g. a `bash -c` script which
+// invokes git. Note the command itself is resolved using the PATH of the test process,
+// see Fake to fake the command itself.
+//
+// Shims are POSIX shell scripts and require /bin/sh.
+type Shims struct {
+	dir string
+	t   testing.TB
+}
+
+// Shim a single shim executable, see also: Shims.Add
+type Shim struct {
+	resp string
+	t    testing.TB
+}
+
+// Invocation a single recorded invocation of a Shim
+type Invocation struct {
+	// Args the name of the shim and the arguments it was invoked with
+	Args []string
+
+	// Dir the working directory of the invocation
+	Dir string
+
+	// Stdin the input read from stdin, if set explicitly to a pipe or regular file
+	// other than the stdin of the test process
+	Stdin []byte
+}
+
+// NewShims returns a new Shims in a temporary directory removed once the given test
+// is complete, with a shim executable for each of the given names
+func NewShims(t testing.TB, names ...string) *Shims {
+	t.Helper()
+
+	s := &Shims{dir: t.TempDir(), t: t}
+
+	for _, dir := range []string{s.binDir(), s.callsDir()} {
+		if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gomnd // directory permissions
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range names {
+		s.Add(name)
+	}
+
+	return s
+}
+
+// Add adds and returns a new shim executable with the given name
+//
+// The shim succeeds with no output unless otherwise configured.
+func (s *Shims) Add(name string) *Shim {
+	s.t.Helper()
+
+	shim := &Shim{resp: filepath.Join(s.dir, "responses", name), t: s.t}
+
+	if err := os.MkdirAll(shim.resp, 0o755); err != nil { //nolint:gomnd // directory permissions
+		s.t.Fatal(err)
+	}
+
+	script := fmt.Sprintf(shimScript, quote(s.callsDir()), quote(shim.resp), quote(name), quote(stdinInode()))
+
+	//nolint:gosec,gomnd // shims must be executable
+	if err := os.WriteFile(filepath.Join(s.binDir(), name), []byte(script), 0o755); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return shim
+}
+
+// Apply prepends the shims directory to the PATH of the given command, including any PATH
+// set via Env, EnvMap or EnvFile, see also: Cmder.Environ
+func (s *Shims) Apply(c cmder.Cmder) cmder.Cmder {
+	path, found := "", false
+
+	for _, e := range c.Environ() {
+		if v, ok := strings.CutPrefix(e, "PATH="); ok {
+			path, found = v, true
+		}
+	}
+
+	if !found {
+		return c.Env("PATH=" + s.binDir())
+	}
+
+	return c.Env(s.pathEnv(path))
+}
+
+// Dir returns the directory containing the shim executables
+func (s *Shims) Dir() string {
+	return s.binDir()
+}
+
+// PathEnv returns the PATH environment variable, in the form "PATH=value", with the
+// shims directory prepended to the PATH of the test process
+func (s *Shims) PathEnv() string {
+	return s.pathEnv(os.Getenv("PATH"))
+}
+
+// pathEnv returns the PATH environment variable, in the form "PATH=value", with the shims
+// directory prepended to the given PATH
+func (s *Shims) pathEnv(path string) string {
+	return "PATH=" + s.binDir() + string(os.PathListSeparator) + path
+}
+
+// Invocations returns each recorded invocation of every shim in order
+func (s *Shims) Invocations() []Invocation {
+	s.t.Helper()
+
+	entries, err := os.ReadDir(s.callsDir())
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	ns := []int{}
+
+	for _, e := range entries {
+		if n, err := strconv.Atoi(e.Name()); err == nil {
+			ns = append(ns, n)
+		}
+	}
+
+	sort.Ints(ns)
+
+	invocations := make([]Invocation, 0, len(ns))
+
+	for _, n := range ns {
+		dir := filepath.Join(s.callsDir(), strconv.Itoa(n))
+
+		argv, err := os.ReadFile(filepath.Join(dir, "argv"))
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		cwd, _ := os.ReadFile(filepath.Join(dir, "cwd"))
+		stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+
+		invocations = append(invocations, Invocation{
+			Args:  strings.Split(strings.TrimSuffix(string(argv), "\x00"), "\x00"),
+			Dir:   strings.TrimSuffix(string(cwd), "\n"),
+			Stdin: stdin,
+		})
+	}
+
+	return invocations
+}
+
+// InvocationsOf returns each recorded invocation of the shim with the given name in order
+func (s *Shims) InvocationsOf(name string) []Invocation {
+	s.t.Helper()
+
+	invocations := []Invocation{}
+
+	for _, i := range s.Invocations() {
+		if i.Args[0] == name {
+			invocations = append(invocations, i)
+		}
+	}
+
+	return invocations
+}
+
+// binDir returns the directory containing the shim executables
+func (s *Shims) binDir() string {
+	return filepath.Join(s.dir, "bin")
+}
+
+// callsDir returns the directory containing the recorded invocations
+func (s *Shims) callsDir() string {
+	return filepath.Join(s.dir, "calls")
+}
+
+// ExitCode sets the exit code of the shim, defaults to 0
+func (s *Shim) ExitCode(code int) *Shim {
+	return s.write("exit", strconv.Itoa(code))
+}
+
+// Script sets a POSIX shell script sourced by the shim after the invocation is recorded,
+// prior to writing the configured output, with the arguments available as "$@"
+//
+// The script may exit early, e.g. `[ "$1" = "status" ] && echo clean && exit 0`.
+func (s *Shim) Script(script string) *Shim {
+	return s.write("script", script)
+}
+
+// Stderr sets the output written to the shim's stderr
+func (s *Shim) Stderr(out string) *Shim {
+	return s.write("stderr", out)
+}
+
+// Stdout sets the output written to the shim's stdout
+func (s *Shim) Stdout(out string) *Shim {
+	return s.write("stdout", out)
+}
+
+// write writes the given response file of the shim
+func (s *Shim) write(name, content string) *Shim {
+	s.t.Helper()
+
+	//nolint:gosec,gomnd // not sensitive
+	if err := os.WriteFile(filepath.Join(s.resp, name), []byte(content), 0o644); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return s
+}
+
+// quote returns the given string single quoted for a POSIX shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmdertest
+
+// stdinInode always returns empty as inodes are not supported on this platform
+func stdinInode() string {
+	return ""
+}
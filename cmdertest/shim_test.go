@@ -0,0 +1,115 @@
+package cmdertest_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/cmdertest"
+)
+
+func Test_ShimsRecordInvocations(t *testing.T) {
+	shims := cmdertest.NewShims(t, "git", "docker")
+	shims.Add("go").Stdout("go version go0.0\n")
+
+	dir := t.TempDir()
+	script := `git status --short && echo "it's input" | docker build -t "a b" - && go version`
+
+	out, err := shims.Apply(cmder.New("bash", "-c", script).Dir(dir)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "go version go0.0\n", string(out))
+
+	expected := [][]string{
+		{"git", "status", "--short"},
+		{"docker", "build", "-t", "a b", "-"},
+		{"go", "version"},
+	}
+	actual := [][]string{}
+
+	for _, i := range shims.Invocations() {
+		actual = append(actual, i.Args)
+		assert.Equal(t, dir, i.Dir)
+	}
+
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+	assert.Equal(t, "it's input\n", string(shims.InvocationsOf("docker")[0].Stdin))
+}
+
+func Test_ShimScriptedResponse(t *testing.T) {
+	shims := cmdertest.NewShims(t)
+	shims.Add("git").Script(`[ "$1" = "status" ] && echo clean && exit 0`).Stderr("fatal\n").ExitCode(128)
+
+	out, err := shims.Apply(cmder.New("bash", "-c", "git status")).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "clean", strings.TrimSpace(string(out)))
+
+	c := shims.Apply(cmder.New("bash", "-c", "git push"))
+	_, err = c.Output()
+
+	assert.Error(t, err)
+	assert.Equal(t, 128, c.ExitCode())
+	assert.Len(t, shims.InvocationsOf("git"), 2)
+}
+
+func Test_ShimInheritedStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer r.Close()
+	defer w.Close()
+
+	// an inherited stdin which is never closed
+	stdin := os.Stdin
+	os.Stdin = r
+
+	defer func() { os.Stdin = stdin }()
+
+	shims := cmdertest.NewShims(t, "git")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = shims.Apply(cmder.New("bash", "-c", "git status; echo in | git add -").Ctx(ctx)).Silent().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invocations := shims.InvocationsOf("git")
+	if len(invocations) != 2 {
+		t.Fatalf("Expected 2 invocations. Got %d.", len(invocations))
+	}
+
+	assert.Empty(t, invocations[0].Stdin)
+	assert.Equal(t, "in\n", string(invocations[1].Stdin))
+}
+
+func Test_ShimsApplyPath(t *testing.T) {
+	shims := cmdertest.NewShims(t, "git")
+	dir := t.TempDir()
+
+	c := shims.Apply(cmder.New("bash", "-c", "git status").Env("PATH=" + dir + ":/usr/bin:/bin"))
+
+	env := c.Environ()
+	assert.Contains(t, env, "PATH="+shims.Dir()+":"+dir+":/usr/bin:/bin")
+
+	if err := c.Silent().Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, shims.InvocationsOf("git"), 1)
+}
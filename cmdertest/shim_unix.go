@@ -0,0 +1,25 @@
+//go:build unix
+
+package cmdertest
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// stdinInode returns the inode of the stdin of the test process, inherited by shims
+// unless the command or script sets stdin, otherwise empty if unknown
+func stdinInode() string {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return ""
+	}
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+
+	return strconv.FormatUint(uint64(st.Ino), 10) //nolint:unconvert // platform dependent
+}
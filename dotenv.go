@@ -0,0 +1,301 @@
+package cmder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	envFiles   []string
+	envFilesMu sync.Mutex
+)
+
+// SetEnvFiles sets the env files loaded into the environment of every command, prior to
+// any env files set via Cmder.EnvFile, see also: Cmder.EnvFile
+//
+// Passing no paths stops loading package level env files.
+func SetEnvFiles(paths ...string) {
+	envFilesMu.Lock()
+	defer envFilesMu.Unlock()
+
+	envFiles = append([]string(nil), paths...)
+}
+
+// getEnvFiles returns a copy of the package level env files
+func getEnvFiles() []string {
+	envFilesMu.Lock()
+	defer envFilesMu.Unlock()
+
+	return append([]string(nil), envFiles...)
+}
+
+// loadEnvFiles returns the environment variables of the given env files in order
+//
+// References to variables are expanded from the given environment and the variables
+// previously loaded, which take precedence.
+func loadEnvFiles(base []string, paths ...string) ([]string, error) {
+	vars := envMap(base)
+	env := []string{}
+
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("env file: %w", err)
+		}
+
+		p := &dotenv{line: 1, s: string(b), vars: vars}
+
+		if err := p.parse(); err != nil {
+			return nil, fmt.Errorf("env file %s:%d: %w", path, p.line, err)
+		}
+
+		env = append(env, p.env...)
+	}
+
+	return env, nil
+}
+
+// dotenv parses the contents of an env file, see also: Cmder.EnvFile
+type dotenv struct {
+	env  []string
+	line int
+	pos  int
+	s    string
+	vars map[string]string
+}
+
+// parse parses each line of the env file
+func (p *dotenv) parse() error {
+	for {
+		p.skipBlanks()
+
+		if p.pos >= len(p.s) {
+			return nil
+		}
+
+		switch p.s[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+
+			continue
+		case '#':
+			p.skipComment()
+			continue
+		}
+
+		if err := p.assignment(); err != nil {
+			return err
+		}
+
+		p.skipBlanks()
+
+		if p.pos < len(p.s) && p.s[p.pos] == '#' {
+			p.skipComment()
+		}
+
+		if p.pos < len(p.s) && p.s[p.pos] != '\n' {
+			return fmt.Errorf("unexpected %q after value", p.s[p.pos])
+		}
+	}
+}
+
+// assignment parses a single KEY=value assignment, optionally prefixed with export
+func (p *dotenv) assignment() error {
+	if rest := p.s[p.pos:]; strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+		p.pos += len("export")
+		p.skipBlanks()
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("= \t\r\n#", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+
+	key := p.s[start:p.pos]
+	if !isName(key) {
+		return fmt.Errorf("invalid variable name %q", key)
+	}
+
+	p.skipBlanks()
+
+	if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+		return fmt.Errorf("expected '=' after %s", key)
+	}
+
+	p.pos++
+	p.skipBlanks()
+
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	p.vars[key] = value
+	p.env = append(p.env, key+"="+value)
+
+	return nil
+}
+
+// value parses a single quoted, double quoted or unquoted value
+func (p *dotenv) value() (string, error) {
+	if p.pos >= len(p.s) {
+		return "", nil
+	}
+
+	switch p.s[p.pos] {
+	case '\'':
+		return p.singleQuoted()
+	case '"':
+		return p.doubleQuoted()
+	}
+
+	return p.unquoted()
+}
+
+// singleQuoted parses a single quoted value, preserved literally, which may span lines
+func (p *dotenv) singleQuoted() (string, error) {
+	line := p.line
+	p.pos++
+
+	end := strings.IndexByte(p.s[p.pos:], '\'')
+	if end < 0 {
+		p.line = line
+		return "", fmt.Errorf("unterminated single quote")
+	}
+
+	value := p.s[p.pos : p.pos+end]
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 1
+
+	return value, nil
+}
+
+// doubleQuoted parses a double quoted value, which may span lines, expanding escape
+// sequences (\n, \r, \t, \", \\ and \$) and variable references
+func (p *dotenv) doubleQuoted() (string, error) {
+	var b strings.Builder
+
+	line := p.line
+	p.pos++
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if p.pos+1 < len(p.s) {
+				p.pos++
+				b.WriteString(unescape(p.s[p.pos]))
+				p.pos++
+
+				continue
+			}
+		case '$':
+			if err := p.expand(&b); err != nil {
+				return "", err
+			}
+
+			continue
+		case '\n':
+			p.line++
+		}
+
+		b.WriteByte(c)
+		p.pos++
+	}
+
+	p.line = line
+
+	return "", fmt.Errorf("unterminated double quote")
+}
+
+// unquoted parses an unquoted value up to the end of the line or a comment, expanding
+// variable references, surrounding blanks are trimmed
+func (p *dotenv) unquoted() (string, error) {
+	var b strings.Builder
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+
+		switch {
+		case c == '\n':
+			return strings.TrimRight(b.String(), " \t\r"), nil
+		case c == '#' && (p.pos == 0 || isBlank(p.s[p.pos-1])):
+			return strings.TrimRight(b.String(), " \t\r"), nil
+		case c == '\'' || c == '"':
+			return "", fmt.Errorf("unexpected quote %q within unquoted value", c)
+		case c == '$':
+			if err := p.expand(&b); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		b.WriteByte(c)
+		p.pos++
+	}
+
+	return strings.TrimRight(b.String(), " \t\r"), nil
+}
+
+// expand writes the expansion of the variable reference at the current '$'
+func (p *dotenv) expand(b *strings.Builder) error {
+	value, n, err := expandVar(p.s[p.pos+1:], func(name string) (string, bool) {
+		v, ok := p.vars[name]
+		return v, ok
+	})
+	if err != nil {
+		return err
+	}
+
+	b.WriteString(value)
+	p.pos += n + 1
+
+	return nil
+}
+
+// skipBlanks advances past any spaces, tabs and carriage returns
+func (p *dotenv) skipBlanks() {
+	for p.pos < len(p.s) && isBlank(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// skipComment advances to the end of the line
+func (p *dotenv) skipComment() {
+	if i := strings.IndexByte(p.s[p.pos:], '\n'); i >= 0 {
+		p.pos += i
+		return
+	}
+
+	p.pos = len(p.s)
+}
+
+// isBlank returns whether the given byte is a space, tab or carriage return
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+// unescape returns the character represented by the given escaped character of a double
+// quoted value, unknown escape sequences are preserved
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(c)
+	}
+
+	return "\\" + string(c)
+}
@@ -0,0 +1,144 @@
+package cmder_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// writeEnvFile writes the given contents to a new env file returning it's path
+func writeEnvFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func Test_EnvFile(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+
+	path := writeEnvFile(t, `# comment
+export A=1
+B = two words # trailing comment
+C='single $A \n
+line'
+D="double $A \"\n\t\$A"
+E=${CMDER_TEST}-${A}-${MISSING:-def}
+F=#not a comment
+
+G=a=b
+H=
+CMDER_TEST=override
+I=$CMDER_TEST
+`)
+
+	env := cmder.New(echo).InheritEnv("CMDER_TEST").EnvFile(path).Environ()
+
+	expected := []string{
+		"A=1",
+		"B=two words",
+		"C=single $A \\n\nline",
+		"D=double 1 \"\n\t$A",
+		"E=orig-1-def",
+		"F=#not a comment",
+		"G=a=b",
+		"H=",
+		"CMDER_TEST=override",
+		"I=override",
+	}
+
+	assert.Equal(t, expected, env)
+}
+
+func Test_EnvFilePrecedence(t *testing.T) {
+	t.Setenv("CMDER_TEST_A", "orig")
+	t.Setenv("CMDER_TEST_B", "orig")
+	t.Setenv("CMDER_TEST_C", "orig")
+	t.Setenv("CMDER_TEST_D", "orig")
+
+	pkg := writeEnvFile(t, "CMDER_TEST_B=pkg\nCMDER_TEST_C=pkg\nCMDER_TEST_D=pkg\n")
+	file := writeEnvFile(t, "CMDER_TEST_C=file\nCMDER_TEST_D=file\n")
+
+	cmder.SetEnvFiles(pkg)
+	t.Cleanup(func() { cmder.SetEnvFiles() })
+
+	out, err := cmder.New("sh", "-c", "echo $CMDER_TEST_A $CMDER_TEST_B $CMDER_TEST_C $CMDER_TEST_D").
+		Env("CMDER_TEST_D=env").
+		EnvFile(file).
+		Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "orig pkg file env\n", string(out))
+}
+
+func Test_EnvFileUnsetenv(t *testing.T) {
+	path := writeEnvFile(t, "CMDER_TEST=file\nCMDER_KEEP=file\n")
+
+	env := cmder.New(echo).ClearEnv().EnvFile(path).Unsetenv("CMDER_TEST").Environ()
+
+	assert.Equal(t, []string{"CMDER_KEEP=file"}, env)
+}
+
+func Test_EnvFileErrors(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected string
+	}{
+		{"A=1\n1B=2\n", `:2: invalid variable name "1B"`},
+		{"A=1\nB\n", `:2: expected '=' after B`},
+		{"A=1\n\nB='a\nb\n", `:3: unterminated single quote`},
+		{"A=\"a\nb\n", `:1: unterminated double quote`},
+		{"A='a' b\n", `:1: unexpected 'b' after value`},
+		{"A=a\"b\"\n", `:1: unexpected quote '"' within unquoted value`},
+		{"A=$(date)\n", `:1: command substitution $(...) is unsupported`},
+	}
+
+	for _, tt := range tests {
+		path := writeEnvFile(t, tt.contents)
+
+		err := cmder.New(echo, foo).EnvFile(path).Out(&bytes.Buffer{}).Run()
+		if assert.Error(t, err, tt.contents) {
+			assert.Contains(t, err.Error(), "env file "+path+tt.expected)
+		}
+
+		err = cmder.New(echo, foo).EnvFile(path).DryRun().Run()
+		assert.Error(t, err, "Expected error in dry run")
+	}
+
+	err := cmder.New(echo, foo).EnvFile(filepath.Join(t.TempDir(), "missing")).Run()
+	assert.True(t, errors.Is(err, fs.ErrNotExist), "Expected fs.ErrNotExist, got: %v", err)
+}
+
+func Test_EnvFileReadOnce(t *testing.T) {
+	path := writeEnvFile(t, "CMDER_TEST=orig\n")
+	logger := &eventLogger{}
+
+	// the env file is rewritten by the command, events describe the environment as executed
+	err := cmder.New("sh", "-c", `echo CMDER_TEST=changed > "$1"`, "sh", path).
+		EnvFile(path).
+		Logger(logger).
+		Silent().
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complete := logger.events[len(logger.events)-1]
+	assert.Equal(t, log.LoggerCompleteKey, complete.Action)
+	assert.Contains(t, complete.Env, "CMDER_TEST=orig")
+}
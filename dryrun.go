@@ -0,0 +1,131 @@
+package cmder
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	dryRunResponses   []*DryRunResponse
+	dryRunResponsesMu sync.Mutex
+)
+
+// DryRunResponse the simulated result of a command run in DryRun mode, see also: OnDryRun
+type DryRunResponse struct {
+	exitCode int
+	match    Matcher
+	stderr   string
+	stdout   string
+}
+
+// dryRunExit the error of a command whose DryRunResponse exits non-zero
+type dryRunExit struct {
+	code int
+}
+
+// OnDryRun registers and returns a new DryRunResponse for commands matching the given Matcher
+//
+// Commands run in DryRun mode are logged and not executed. By default Run returns nil and
+// Output returns nil output. Commands matching a registered DryRunResponse instead
+// write the simulated stdout and stderr, and exit with the simulated exit code, such that
+// code parsing the output of commands can be exercised in DryRun mode.
+//
+// Commands are matched against each DryRunResponse in the order registered, the first
+// matching DryRunResponse is used. See also: ResetDryRun
+func OnDryRun(m Matcher) *DryRunResponse {
+	dryRunResponsesMu.Lock()
+	defer dryRunResponsesMu.Unlock()
+
+	r := &DryRunResponse{match: m}
+	dryRunResponses = append(dryRunResponses, r)
+
+	return r
+}
+
+// ResetDryRun removes every DryRunResponse registered via OnDryRun
+func ResetDryRun() {
+	dryRunResponsesMu.Lock()
+	defer dryRunResponsesMu.Unlock()
+
+	dryRunResponses = nil
+}
+
+// ExitCode sets the simulated exit code of the command, defaults to 0
+//
+// A non-zero exit code results in an *Error with StatusFailed.
+func (r *DryRunResponse) ExitCode(code int) *DryRunResponse {
+	r.exitCode = code
+	return r
+}
+
+// Stderr sets the simulated output written to the command's stderr
+func (r *DryRunResponse) Stderr(s string) *DryRunResponse {
+	r.stderr = s
+	return r
+}
+
+// Stdout sets the simulated output written to the command's stdout
+func (r *DryRunResponse) Stdout(s string) *DryRunResponse {
+	r.stdout = s
+	return r
+}
+
+// Error implements the error interface
+func (e *dryRunExit) Error() string {
+	return fmt.Sprintf("exit status %d (dry run)", e.code)
+}
+
+// ExitStatus returns the simulated exit code
+func (e *dryRunExit) ExitStatus() int {
+	return e.code
+}
+
+// dryRunResponse returns the first DryRunResponse matching the given arguments, if any
+func dryRunResponse(args []string) *DryRunResponse {
+	dryRunResponsesMu.Lock()
+	defer dryRunResponsesMu.Unlock()
+
+	for _, r := range dryRunResponses {
+		if r.match(args) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// simulate writes the output of the DryRunResponse matching the cmd, if any, to the given
+// writers, setting the end state of the cmd with the simulated exit code
+//
+// returns nil if no DryRunResponse matches the cmd, any error building the cmd, e.g.
+// loading it's env files, is returned regardless
+func (c *cmd) simulate(stdout, stderr io.Writer) error {
+	if c.buildErr != nil {
+		c.start = time.Now()
+		return c.endState(c.buildErr)
+	}
+
+	r := dryRunResponse(c.state().args)
+	if r == nil {
+		return nil
+	}
+
+	c.start = time.Now()
+
+	if stdout != nil {
+		_, _ = io.WriteString(stdout, r.stdout)
+	}
+
+	if stderr != nil {
+		_, _ = io.WriteString(stderr, r.stderr)
+	}
+
+	var err error
+	if r.exitCode != 0 {
+		err = &dryRunExit{code: r.exitCode}
+	}
+
+	return c.endState(err)
+}
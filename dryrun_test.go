@@ -0,0 +1,79 @@
+package cmder_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_DryRunOutput(t *testing.T) {
+	t.Cleanup(cmder.ResetDryRun)
+
+	expected := "v1.2.3\n"
+
+	cmder.OnDryRun(cmder.Exact("git", "describe", "--tags")).Stdout(expected)
+
+	actual, err := cmder.New("git", "describe", "--tags").DryRun().Output()
+	if err != nil {
+		t.Error(err)
+	}
+
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, string(actual), msg)
+
+	actual, err = cmder.New("git", "status").DryRun().Output()
+	assert.NoError(t, err)
+	assert.Nil(t, actual, "Expected nil output for commands without a DryRunResponse")
+}
+
+func Test_DryRunExitCode(t *testing.T) {
+	t.Cleanup(cmder.ResetDryRun)
+
+	cmder.OnDryRun(cmder.HasPrefix("docker", "push")).Stderr("denied\n").ExitCode(1)
+	cmder.OnDryRun(cmder.Regexp(`^docker`)).Stdout("ok\n")
+
+	var stdout, stderr bytes.Buffer
+
+	c := cmder.New("docker", "push", "image").DryRun()
+	err := c.Run(&stdout, &stderr)
+
+	var cmdErr *cmder.Error
+	assert.True(t, errors.As(err, &cmdErr), "Expected *cmder.Error. Got %T.", err)
+	assert.Equal(t, 1, c.ExitCode())
+	assert.Equal(t, cmder.StatusFailed, c.Status())
+	assert.Equal(t, "denied\n", stderr.String())
+	assert.Equal(t, "denied\n", string(cmdErr.Stderr))
+
+	c = cmder.New("docker", "build", ".").Out(&stdout).DryRun()
+
+	err = c.Start()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = c.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n", stdout.String())
+}
+
+func Test_DryRunPipe(t *testing.T) {
+	t.Cleanup(cmder.ResetDryRun)
+
+	cmder.OnDryRun(cmder.HasPrefix("kubectl")).Stdout("pod-a\npod-b\n")
+	cmder.OnDryRun(cmder.HasPrefix("wc")).Stdout("2\n")
+
+	actual, err := cmder.Pipe(
+		cmder.New("kubectl", "get", "pods"),
+		cmder.New("wc", "-l"),
+	).DryRun().Output()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, "2\n", string(actual))
+}
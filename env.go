@@ -0,0 +1,248 @@
+package cmder
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// envDelta the environment of a command relative to the environment of the calling process
+type envDelta struct {
+	// clear whether the environment of the calling process is not inherited
+	clear bool
+
+	// env the effective environment of the command
+	env []string
+
+	// set the entries of env not found in the environment of the calling process
+	set []string
+
+	// unset the keys of the environment of the calling process not found in env
+	unset []string
+}
+
+// newEnvDelta returns the envDelta of the given environment relative to the given base
+func newEnvDelta(env, base []string, clear bool) envDelta {
+	env = dedupEnv(env)
+	base = dedupEnv(base)
+
+	d := envDelta{clear: clear, env: env, set: envAdditions(env, base), unset: []string{}}
+	m := envMap(env)
+
+	for _, e := range base {
+		k, _, _ := strings.Cut(e, "=")
+		if _, ok := m[k]; !ok {
+			d.unset = append(d.unset, k)
+		}
+	}
+
+	return d
+}
+
+// prefix returns the words prefixing a POSIX shell command to run it with the environment
+// `env -i` if the environment is cleared, `env -u` for each unset variable and `VAR=value`
+// for each variable set
+func (d envDelta) prefix() string {
+	var b strings.Builder
+
+	set := d.set
+
+	switch {
+	case d.clear:
+		b.WriteString("env -i ")
+
+		set = d.env
+	case len(d.unset) > 0:
+		b.WriteString("env ")
+
+		for _, k := range d.unset {
+			b.WriteString("-u " + shellQuote(k) + " ")
+		}
+	}
+
+	for _, e := range set {
+		k, v, _ := strings.Cut(e, "=")
+		b.WriteString(k + "=" + shellQuote(v) + " ")
+	}
+
+	return b.String()
+}
+
+// resolved the environment, arguments and working directory of the cmd resolved once per
+// execution by buildExec, such that env files are read and arguments expanded and globbed
+// once and consistently reported, see also: cmd.state
+type resolved struct {
+	// args the command and arguments as executed, see also: cmd.argv
+	args []string
+
+	// delta the environment relative to the calling process
+	delta envDelta
+
+	// dir the working directory as executed
+	dir string
+
+	// env the effective environment, see also: cmd.environ
+	env []string
+
+	// err the error resolving the environment or arguments, e.g. loading an env file
+	err error
+}
+
+// resolve returns the environment, arguments and working directory of the cmd, reading any
+// env files prior to expanding and globbing the arguments
+func (c *cmd) resolve() *resolved {
+	env, err := c.environ()
+	args, dir, argvErr := c.argv(env)
+
+	if err == nil {
+		err = argvErr
+	}
+
+	return &resolved{
+		args:  args,
+		delta: newEnvDelta(env, os.Environ(), c.envCleared),
+		dir:   dir,
+		env:   env,
+		err:   err,
+	}
+}
+
+// state returns the environment, arguments and working directory resolved for the current
+// execution of the cmd, or resolves them if the cmd has not been built, see also: buildExec
+func (c *cmd) state() *resolved {
+	if c.resolved != nil {
+		return c.resolved
+	}
+
+	return c.resolve()
+}
+
+// invalidate discards the state resolved for the previous execution of the cmd, such that
+// it's resolved again once the cmd is modified, see also: state
+func (c *cmd) invalidate() {
+	c.resolved = nil
+}
+
+// envDelta returns the environment of the cmd relative to the calling process
+func (c *cmd) envDelta() envDelta {
+	return c.state().delta
+}
+
+// environ returns the environment of the cmd, in order of precedence: the inherited
+// environment, the variables loaded from the package level env files, the variables
+// loaded from the cmd's env files and the variables added via Env
+//
+// Variables loaded from env files are removed if unset via Unsetenv.
+func (c *cmd) environ() ([]string, error) {
+	files := append(getEnvFiles(), c.envFiles...)
+	if len(files) == 0 {
+		return c.env, nil
+	}
+
+	loaded, err := loadEnvFiles(c.env[:c.envBase], files...)
+	if err != nil {
+		return c.env, err
+	}
+
+	loaded = filterEnv(loaded, func(k string) bool {
+		return !matchKey(k, c.envUnset)
+	})
+
+	env := make([]string, 0, len(c.env)+len(loaded))
+	env = append(env, c.env[:c.envBase]...)
+	env = append(env, loaded...)
+
+	return append(env, c.env[c.envBase:]...), nil
+}
+
+// dedupEnv returns the given environment with only the last value of each duplicate key,
+// in the order of the last occurrence of each key, as per os/exec
+func dedupEnv(env []string) []string {
+	seen := make(map[string]bool, len(env))
+	deduped := make([]string, 0, len(env))
+
+	for i := len(env) - 1; i >= 0; i-- {
+		k, _, _ := strings.Cut(env[i], "=")
+		if seen[k] {
+			continue
+		}
+
+		seen[k] = true
+		deduped = append(deduped, env[i])
+	}
+
+	for i, j := 0, len(deduped)-1; i < j; i, j = i+1, j-1 {
+		deduped[i], deduped[j] = deduped[j], deduped[i]
+	}
+
+	return deduped
+}
+
+// filterEnv returns the entries of the given environment for which keep returns true
+func filterEnv(env []string, keep func(key string) bool) []string {
+	filtered := make([]string, 0, len(env))
+
+	for _, e := range env {
+		k, _, _ := strings.Cut(e, "=")
+		if keep(k) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// matchKey returns whether the given environment variable key matches any of the given
+// patterns, as per path.Match
+func matchKey(key string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapEnv returns the given map as sorted environment entries of the form "key=value"
+func mapEnv(m map[string]string) []string {
+	env := make([]string, 0, len(m))
+	for k, v := range m {
+		env = append(env, k+"="+v)
+	}
+
+	sort.Strings(env)
+
+	return env
+}
+
+// envAdditions returns the entries of env not found in base
+func envAdditions(env, base []string) []string {
+	set := make(map[string]bool, len(base))
+	for _, e := range base {
+		set[e] = true
+	}
+
+	additions := []string{}
+
+	for _, e := range env {
+		if !set[e] {
+			additions = append(additions, e)
+		}
+	}
+
+	return additions
+}
+
+// envMap returns the given environment as a map, the last value of duplicate keys is used
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		m[k] = v
+	}
+
+	return m
+}
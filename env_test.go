@@ -0,0 +1,117 @@
+package cmder_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_Environ(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+
+	env := cmder.New(echo).Env("CMDER_TEST=1", "CMDER_TEST=2").Environ()
+
+	assert.Contains(t, env, "CMDER_TEST=2")
+	assert.NotContains(t, env, "CMDER_TEST=1")
+	assert.NotContains(t, env, "CMDER_TEST=orig")
+}
+
+func Test_EnvMap(t *testing.T) {
+	actual := cmder.New(echo).ClearEnv().EnvMap(map[string]string{"B": "2", "A": "1"}).Environ()
+
+	assert.Equal(t, []string{"A=1", "B=2"}, actual)
+}
+
+func Test_Unsetenv(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+	t.Setenv("CMDER_TEST_A", "a")
+	t.Setenv("CMDER_KEEP", "keep")
+
+	out, err := cmder.New("env").Env("CMDER_ADDED=1").Unsetenv("CMDER_TEST*", "CMDER_ADDED").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotContains(t, string(out), "CMDER_TEST")
+	assert.NotContains(t, string(out), "CMDER_ADDED")
+	assert.Contains(t, string(out), "CMDER_KEEP=keep")
+}
+
+func Test_ClearEnv(t *testing.T) {
+	out, err := cmder.New("env").ClearEnv().Env("FOO=bar").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FOO=bar\n", string(out))
+}
+
+func Test_InheritEnv(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+	t.Setenv("CMDER_SECRET", "secret")
+
+	out, err := cmder.New("env").Env("FOO=bar").InheritEnv("CMDER_TEST").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CMDER_TEST=orig\n", string(out))
+}
+
+func Test_EnvShellString(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+
+	actual := cmder.New(echo, foo).Unsetenv("CMDER_TEST").Env("FOO=a b").ShellString()
+	assert.Equal(t, "env -u CMDER_TEST FOO='a b' echo foo", actual)
+
+	actual = cmder.New(echo, foo).ClearEnv().Env("FOO=a b").ShellString()
+	assert.Equal(t, "env -i FOO='a b' echo foo", actual)
+}
+
+func Test_LogEventEnv(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+	t.Setenv("CMDER_UNSET", "unset")
+
+	logger := &eventLogger{}
+
+	err := cmder.New(echo).Logger(logger).Env("FOO=bar", "CMDER_TEST=new").Unsetenv("CMDER_UNSET").DryRun().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := logger.events[0]
+	assert.Equal(t, []string{"FOO=bar", "CMDER_TEST=new"}, e.Env)
+	assert.Equal(t, []string{"CMDER_UNSET"}, e.Unset)
+	assert.True(t, strings.HasPrefix(e.ShellEnv, "env -u CMDER_UNSET "), e.ShellEnv)
+	assert.NotContains(t, e.Shell, "FOO=bar")
+	assert.NotContains(t, e.Shell, "CMDER_UNSET")
+}
+
+func Test_EnvAfterRun(t *testing.T) {
+	logger := &eventLogger{}
+
+	c := cmder.New(echo, foo).Logger(logger).Out(&bytes.Buffer{})
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Env("FOO=bar").LogCmd()
+
+	e := logger.events[len(logger.events)-1]
+	assert.Equal(t, []string{"FOO=bar"}, e.Env)
+
+	p := cmder.Pipe(cmder.New(echo, foo), cmder.New(cat)).Logger(logger)
+	if err := p.Run(&bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Cmds()[1].Env("BAR=1")
+	p.LogCmd()
+
+	e = logger.events[len(logger.events)-1]
+	assert.Equal(t, "echo foo | BAR=1 cat", e.ShellEnv)
+}
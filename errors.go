@@ -0,0 +1,184 @@
+package cmder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StderrTailSize the number of trailing bytes of a command's stderr retained on Error
+//
+// Stderr is captured in addition to being written to the command's configured stderr,
+// including os.Stderr, see also: OutputWaitDelay
+var StderrTailSize = 4 << 10 //nolint:gomnd // 4KiB
+
+// OutputWaitDelay the time waited, once a command's process has exited, for it's captured
+// output to be closed, e.g. by background processes which inherited it, prior to closing it
+//
+// Output written after the delay is discarded. If a GracePeriod is set it is used instead.
+// See also: exec.Cmd WaitDelay
+var OutputWaitDelay = time.Second
+
+// Error is returned when a command fails to run or exits unsuccessfully
+//
+// Error wraps the original error, typically an *exec.ExitError, which can be
+// retrieved with errors.As or Unwrap.
+type Error struct {
+	// Args the command and arguments executed
+	Args []string
+
+	// Dir the working directory of the command
+	Dir string
+
+	// Duration the time the command ran prior to failing
+	Duration time.Duration
+
+	// Err the underlying error
+	Err error
+
+	// ExitCode the exit code of the command or -1 if the command did not exit
+	ExitCode int
+
+	// Signal the signal which terminated the command, if any
+	Signal os.Signal
+
+	// Status the classification of how the command exited
+	Status Status
+
+	// Stderr the last StderrTailSize bytes written to the command's stderr, see also:
+	// StderrTailSize
+	Stderr []byte
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%v", e.Args)
+
+	if e.Dir != "" {
+		fmt.Fprintf(&b, " in %s", e.Dir)
+	}
+
+	fmt.Fprintf(&b, ": %v", e.Err)
+
+	if e.Status.err() != nil && !errors.Is(e.Err, e.Status.err()) {
+		fmt.Fprintf(&b, " (%s)", e.Status)
+	}
+
+	if e.Duration > 0 {
+		fmt.Fprintf(&b, " after %s", e.Duration)
+	}
+
+	if line := lastLine(e.Stderr); line != "" {
+		fmt.Fprintf(&b, ": %s", line)
+	}
+
+	return b.String()
+}
+
+// Is reports whether the Error matches the given target
+// in addition to the underlying error, the sentinel error matching Status is matched
+// e.g. errors.Is(err, ErrTimeout)
+func (e *Error) Is(target error) bool {
+	sentinel := e.Status.err()
+	return sentinel != nil && sentinel == target //nolint:errorlint // comparing sentinel errors
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError returns a new *Error for the given cmd wrapping err
+// returns nil if err is nil and err if it is already an *Error
+func (c *cmd) newError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	r := c.state()
+
+	e = &Error{
+		Args:     append([]string(nil), r.args...),
+		Dir:      r.dir,
+		Err:      err,
+		ExitCode: c.exitCode,
+		Signal:   c.signal,
+		Status:   c.status,
+	}
+
+	if !c.start.IsZero() {
+		e.Duration = c.Duration()
+	}
+
+	if c.stderrTail != nil {
+		e.Stderr = c.stderrTail.Bytes()
+	}
+
+	var exitErr *exec.ExitError
+	if len(e.Stderr) == 0 && errors.As(err, &exitErr) {
+		e.Stderr = tail(exitErr.Stderr, StderrTailSize)
+	}
+
+	return e
+}
+
+// signaled an interface implementing the Signaled and Signal methods
+// see also: syscall.WaitStatus
+type signaled interface {
+	Signaled() bool
+	Signal() syscall.Signal
+}
+
+// exitSignal returns the signal which terminated the process for the given error, if any
+func exitSignal(err error) os.Signal {
+	var s signaled
+	if errors.As(err, &s) && s.Signaled() {
+		return s.Signal()
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ProcessState == nil {
+		return nil
+	}
+
+	if ws, ok := exitErr.Sys().(signaled); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+
+	return nil
+}
+
+// lastLine returns the last non-empty line of the given bytes
+func lastLine(b []byte) string {
+	b = bytes.TrimRight(b, "\r\n\t ")
+	if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+		b = b[i+1:]
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// tail returns the last n bytes of b
+func tail(b []byte, n int) []byte {
+	if n <= 0 {
+		return nil
+	}
+
+	if len(b) > n {
+		b = b[len(b)-n:]
+	}
+
+	return b
+}
@@ -0,0 +1,132 @@
+package cmder_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_ErrorAs(t *testing.T) {
+	var stderr bytes.Buffer
+
+	err := cmder.New("bash", "-c", "echo oops >&2; exit 1").
+		Dir(tmp).
+		Out(&bytes.Buffer{}, &stderr).
+		Run()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %T.", err)
+	}
+
+	assert.Equal(t, []string{"bash", "-c", "echo oops >&2; exit 1"}, cmdErr.Args)
+	assert.Equal(t, tmp, cmdErr.Dir)
+	assert.Equal(t, 1, cmdErr.ExitCode)
+	assert.Nil(t, cmdErr.Signal)
+	assert.Positive(t, cmdErr.Duration)
+	assert.Equal(t, "oops\n", string(cmdErr.Stderr))
+	assert.Equal(t, "oops\n", stderr.String(), "stderr expected to still be written")
+
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr), "Expected error to wrap *exec.ExitError")
+}
+
+func Test_ErrorString(t *testing.T) {
+	err := cmder.New("bash", "-c", "echo first >&2; echo last >&2; exit 1").
+		Out(&bytes.Buffer{}, &bytes.Buffer{}).
+		Run()
+
+	actual := err.Error()
+	for _, expected := range []string{"[bash -c", "exit status 1", ": last"} {
+		msg := fmt.Sprintf("Expected '%s' to contain '%s'", actual, expected)
+		assert.True(t, strings.Contains(actual, expected), msg)
+	}
+}
+
+func Test_ErrorOutputStderr(t *testing.T) {
+	_, err := cmder.New("bash", "-c", "echo oops >&2; exit 1").Output()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %T.", err)
+	}
+
+	assert.Equal(t, "oops\n", string(cmdErr.Stderr))
+}
+
+func Test_ErrorStderrTail(t *testing.T) {
+	orig := cmder.StderrTailSize
+	defer func() { cmder.StderrTailSize = orig }()
+
+	cmder.StderrTailSize = 4
+
+	err := cmder.New("bash", "-c", "printf 0123456789 >&2; exit 1").
+		Out(&bytes.Buffer{}, &bytes.Buffer{}).
+		Run()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %T.", err)
+	}
+
+	assert.Equal(t, "6789", string(cmdErr.Stderr))
+}
+
+func Test_ErrorNotFound(t *testing.T) {
+	err := cmder.New("cmder-does-not-exist").Run()
+
+	var cmdErr *cmder.Error
+	assert.True(t, errors.As(err, &cmdErr), "Expected *cmder.Error")
+	assert.True(t, errors.Is(err, exec.ErrNotFound), "Expected error to wrap exec.ErrNotFound")
+}
+
+func Test_ErrorStderrFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// a background process inheriting stderr must not delay Run beyond OutputWaitDelay
+	c := cmder.New("bash", "-c", "sleep 3 & echo oops >&2; exit 1").Out(f, f).Silent()
+	err = c.Run()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %T.", err)
+	}
+
+	assert.Less(t, c.Duration(), 2*time.Second, "Expected Run not to wait on background processes")
+	assert.Equal(t, "oops\n", string(cmdErr.Stderr), "Expected stderr files to be captured")
+
+	b, _ := os.ReadFile(f.Name())
+	assert.Equal(t, "oops\n", string(b))
+}
+
+func Test_ErrorStderrDefault(t *testing.T) {
+	err := cmder.New("bash", "-c", "echo oops >&2; exit 1").Silent().Run()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %T.", err)
+	}
+
+	assert.Equal(t, "oops\n", string(cmdErr.Stderr), "Expected os.Stderr to be captured")
+}
+
+func Test_OutputWaitDelay(t *testing.T) {
+	c := cmder.New("bash", "-c", "sleep 3 & echo ok").Out(&bytes.Buffer{}).Silent()
+
+	assert.NoError(t, c.Run(), "Expected a successful exit despite the background process")
+	assert.Less(t, c.Duration(), 2*time.Second)
+}
@@ -0,0 +1,112 @@
+package cmder
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Executor executes the underlying *exec.Cmd built for each command
+//
+// By default commands are executed by os/exec. An alternative Executor can be set
+// for all commands via SetExecutor, or for a single command via Cmder.Executor,
+// e.g. to fake the execution of commands in tests (see the cmdertest package).
+//
+// The *exec.Cmd passed to each method is fully configured: Path, Args, Dir, Env,
+// Stdin, Stdout and Stderr are set as they would be for os/exec.
+//
+// Commands are executed via Start and Wait, such that the process may be signaled while
+// running, e.g. via Cmder.Terminate. Run and Output are provided for executing an
+// *exec.Cmd directly, e.g. by an Executor wrapping another.
+type Executor interface {
+	// Output runs the command and returns its standard output, see also: exec.Cmd.Output
+	Output(c *exec.Cmd) ([]byte, error)
+
+	// Run starts the command and waits for it to complete, see also: exec.Cmd.Run
+	Run(c *exec.Cmd) error
+
+	// Signal sends the given signal to the started command, see also: os.Process.Signal
+	Signal(c *exec.Cmd, sig os.Signal) error
+
+	// Start starts the command without waiting for it to complete, see also: exec.Cmd.Start
+	Start(c *exec.Cmd) error
+
+	// Wait waits for the started command to complete, see also: exec.Cmd.Wait
+	Wait(c *exec.Cmd) error
+}
+
+var (
+	executor   Executor = osExecutor{}
+	executorMu sync.Mutex
+)
+
+// SetExecutor sets the Executor used by all commands which have not had one set via
+// Cmder.Executor. Passing nil restores the default os/exec Executor.
+func SetExecutor(e Executor) {
+	executorMu.Lock()
+	defer executorMu.Unlock()
+
+	if e == nil {
+		e = osExecutor{}
+	}
+
+	executor = e
+}
+
+// DefaultExecutor returns the default Executor, executing commands with os/exec
+//
+// Useful for an Executor wrapping the execution of commands, e.g. to record them.
+func DefaultExecutor() Executor {
+	return osExecutor{}
+}
+
+// getExecutor returns the package level Executor
+func getExecutor() Executor {
+	executorMu.Lock()
+	defer executorMu.Unlock()
+
+	return executor
+}
+
+// osExecutor the default Executor, executing commands with os/exec
+type osExecutor struct{}
+
+// Output implements the Executor interface
+func (osExecutor) Output(c *exec.Cmd) ([]byte, error) {
+	return c.Output()
+}
+
+// Run implements the Executor interface
+func (osExecutor) Run(c *exec.Cmd) error {
+	return c.Run()
+}
+
+// Signal implements the Executor interface
+//
+// if the command was started in it's own process group the entire group is signaled
+func (osExecutor) Signal(c *exec.Cmd, sig os.Signal) error {
+	if isGroupLeader(c) {
+		return signalGroup(c.Process, sig)
+	}
+
+	return c.Process.Signal(sig)
+}
+
+// Start implements the Executor interface
+func (osExecutor) Start(c *exec.Cmd) error {
+	return c.Start()
+}
+
+// Wait implements the Executor interface
+func (osExecutor) Wait(c *exec.Cmd) error {
+	return c.Wait()
+}
+
+// getExecutor returns the Executor for the cmd, defaulting to the package Executor
+func (c *cmd) getExecutor() Executor {
+	if c.executor != nil {
+		return c.executor
+	}
+
+	return getExecutor()
+}
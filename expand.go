@@ -0,0 +1,223 @@
+package cmder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// expandVar expands the variable reference following a '$' at the start of s, one of:
+// $NAME, ${NAME}, ${NAME:-default} or ${NAME-default}, using the given lookup
+//
+// returns the expanded value and the number of bytes of s consumed. A '$' not followed
+// by a variable name or '{' is returned as is, consuming nothing. Special parameters,
+// e.g. $1 or $@, and command substitution are unsupported.
+func expandVar(s string, lookup func(string) (string, bool)) (value string, n int, err error) {
+	if s == "" {
+		return "$", 0, nil
+	}
+
+	switch c := s[0]; {
+	case c == '(':
+		return "", 0, fmt.Errorf("command substitution $(...) is unsupported")
+	case c == '{':
+		return expandBraced(s, lookup)
+	case isNameStart(c):
+		n = 1
+		for n < len(s) && isNameChar(s[n]) {
+			n++
+		}
+
+		value, _ = lookup(s[:n])
+
+		return value, n, nil
+	case strings.IndexByte("0123456789@*#?-$!", c) >= 0:
+		return "", 0, fmt.Errorf("special parameter $%c is unsupported", c)
+	}
+
+	return "$", 0, nil
+}
+
+// expandBraced expands the braced variable reference at the start of s, see also: expandVar
+func expandBraced(s string, lookup func(string) (string, bool)) (value string, n int, err error) {
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated ${")
+	}
+
+	ref := s[1:end]
+	name := ref
+
+	var (
+		def    string
+		hasDef bool
+		colon  bool
+	)
+
+	if i := strings.IndexAny(ref, ":-"); i >= 0 {
+		name = ref[:i]
+
+		switch {
+		case strings.HasPrefix(ref[i:], ":-"):
+			def, hasDef, colon = ref[i+2:], true, true
+		case ref[i] == '-':
+			def, hasDef = ref[i+1:], true
+		default:
+			return "", 0, fmt.Errorf("unsupported parameter expansion ${%s}", ref)
+		}
+	}
+
+	if !isName(name) {
+		return "", 0, fmt.Errorf("invalid variable name ${%s}", ref)
+	}
+
+	value, ok := lookup(name)
+	if hasDef && (!ok || (colon && value == "")) {
+		value = def
+	}
+
+	return value, end + 1, nil
+}
+
+// isName returns whether the given string is a valid POSIX shell variable name
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+
+	for i := 1; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isNameStart returns whether the given byte may start a variable name
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isNameChar returns whether the given byte may be part of a variable name
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// ErrUndefinedVar returned by commands expanded in strict mode which reference an
+// undefined variable without a default, see also: Cmder.ExpandStrict
+var ErrUndefinedVar = errors.New("undefined variable")
+
+// argv returns the arguments and working directory of the cmd as executed, with variable
+// references expanded from the given environment of the cmd if Expand is set and the
+// arguments other than the command globbed if Glob is set
+//
+// If expansion fails the arguments and working directory are returned unexpanded.
+func (c *cmd) argv(env []string) (args []string, dir string, err error) {
+	if (!c.expand && !c.glob) || len(c.strings) == 0 {
+		return c.strings, c.dir, nil
+	}
+
+	expand := func(s string) (string, error) {
+		return s, nil
+	}
+
+	if c.expand {
+		vars := envMap(env)
+
+		lookup := func(name string) (string, bool) {
+			v, ok := vars[name]
+			return v, ok
+		}
+
+		expand = func(s string) (string, error) {
+			expanded, err := expandString(s, lookup, c.expandStrict)
+			if err != nil {
+				return "", fmt.Errorf("expand: %w in %q", err, s)
+			}
+
+			return expanded, nil
+		}
+	}
+
+	if dir, err = expand(c.dir); err != nil {
+		return c.strings, c.dir, err
+	}
+
+	name, err := expand(c.strings[0])
+	if err != nil {
+		return c.strings, c.dir, err
+	}
+
+	args = []string{name}
+
+	if c.glob {
+		globbed, err := globArgs(c.strings[1:], dir, c.globMode, expand)
+		if err != nil {
+			return c.strings, c.dir, err
+		}
+
+		return append(args, globbed...), dir, nil
+	}
+
+	for _, a := range c.strings[1:] {
+		expanded, err := expand(a)
+		if err != nil {
+			return c.strings, c.dir, err
+		}
+
+		args = append(args, expanded)
+	}
+
+	return args, dir, nil
+}
+
+// expandString returns the given string with each variable reference expanded using the
+// given lookup, see also: expandVar. `$$` is expanded to a literal '$'.
+//
+// If strict, references to variables which are undefined and have no default return
+// ErrUndefinedVar.
+func expandString(s string, lookup func(string) (string, bool), strict bool) (string, error) {
+	var b strings.Builder
+
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		b.WriteString(s[:i])
+		s = s[i+1:]
+
+		if strings.HasPrefix(s, "$") {
+			b.WriteByte('$')
+			s = s[1:]
+
+			continue
+		}
+
+		undefined := ""
+
+		value, n, err := expandVar(s, func(name string) (string, bool) {
+			v, ok := lookup(name)
+			if !ok {
+				undefined = name
+			}
+
+			return v, ok
+		})
+		if err != nil {
+			return "", err
+		}
+
+		// braced references may only contain a '-' as part of a default
+		hasDefault := strings.HasPrefix(s, "{") && strings.Contains(s[:n], "-")
+		if strict && undefined != "" && !hasDefault {
+			return "", fmt.Errorf("%w $%s", ErrUndefinedVar, undefined)
+		}
+
+		b.WriteString(value)
+		s = s[n:]
+	}
+}
@@ -0,0 +1,108 @@
+package cmder_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_Expand(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+	t.Setenv("CMDER_EMPTY", "")
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"$CMDER_TEST"}, "orig\n"},
+		{[]string{"${CMDER_TEST}-${VERSION}"}, "orig-1.2.3\n"},
+		{[]string{"a b=$VERSION", "$$VERSION"}, "a b=1.2.3 $VERSION\n"},
+		{[]string{"${MISSING:-def}", "${CMDER_EMPTY:-def}", "${CMDER_EMPTY-def}."}, "def def .\n"},
+		{[]string{"$MISSING", "$", "a$"}, " $ a$\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := cmder.New(echo).Args(tt.args...).Env("VERSION=1.2.3").Expand().Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg := fmt.Sprintf("Expected '%s' Got '%s'", tt.expected, out)
+		assert.Equal(t, tt.expected, string(out), msg)
+	}
+}
+
+func Test_ExpandDir(t *testing.T) {
+	t.Setenv("CMDER_DIR", tmp)
+
+	out, err := cmder.New("pwd").Dir("${CMDER_DIR}").Expand().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, tmp+"\n", string(out))
+}
+
+func Test_ExpandUnset(t *testing.T) {
+	out, err := cmder.New(echo, "$HOME").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "$HOME\n", string(out), "Expected arguments not to be expanded")
+}
+
+func Test_ExpandStrict(t *testing.T) {
+	err := cmder.New(echo, "${CMDER_MISSING}").ExpandStrict().Silent().Run()
+	assert.True(t, errors.Is(err, cmder.ErrUndefinedVar), "Expected ErrUndefinedVar, got: %v", err)
+
+	err = cmder.New(echo, "${CMDER_MISSING}").ExpandStrict().DryRun().Run()
+	assert.True(t, errors.Is(err, cmder.ErrUndefinedVar), "Expected ErrUndefinedVar in dry run, got: %v", err)
+
+	err = cmder.New(echo, "${CMDER_MISSING:-def}").ExpandStrict().DryRun().Run()
+	assert.NoError(t, err)
+
+	err = cmder.New(echo, "$(date)").Expand().DryRun().Run()
+	assert.Error(t, err)
+}
+
+func Test_ExpandLog(t *testing.T) {
+	logger := &eventLogger{}
+
+	err := cmder.New(echo, "$VERSION").Env("VERSION=1.2.3").Expand().Logger(logger).DryRun().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := logger.events[0]
+	assert.Equal(t, []string{echo, "$VERSION"}, e.Template)
+	assert.Equal(t, []string{echo, "1.2.3"}, e.Args)
+	assert.Contains(t, e.Message, "[echo $VERSION] -> [echo 1.2.3]")
+	assert.Contains(t, e.Shell, "echo 1.2.3")
+}
+
+func Test_ArgsAfterRun(t *testing.T) {
+	logger := &eventLogger{}
+
+	c := cmder.New(echo, "a").Logger(logger).Out(&bytes.Buffer{})
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Dir(tmp).Args("b").LogCmd()
+
+	e := logger.events[len(logger.events)-1]
+	assert.Equal(t, []string{echo, "a", "b"}, e.Args)
+	assert.Equal(t, tmp, e.Dir)
+	assert.Equal(t, "cd /tmp && echo a b", e.Shell)
+
+	c.Args("$CMDER_TEST").Env("CMDER_TEST=c").Expand().LogCmd()
+
+	e = logger.events[len(logger.events)-1]
+	assert.Equal(t, []string{echo, "a", "b", "c"}, e.Args)
+}
@@ -0,0 +1,290 @@
+package cmder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GlobMode the behavior of a glob pattern which matches no files, see also: Cmder.Glob
+type GlobMode int
+
+const (
+	// GlobLiteral passes a pattern which matches no files as is, as a POSIX shell would
+	GlobLiteral GlobMode = iota
+
+	// GlobNull removes a pattern which matches no files, as bash would with nullglob set
+	GlobNull
+
+	// GlobFail fails the command if a pattern matches no files, as bash would with failglob
+	// set, returning an error wrapping ErrNoMatch
+	GlobFail
+)
+
+// ErrNoMatch returned by commands with GlobFail set when a glob pattern matches no files
+var ErrNoMatch = errors.New("no match")
+
+// globArgs returns the given arguments with brace expressions expanded and glob patterns
+// expanded to the sorted paths they match, relative to the given dir, see also: Cmder.Glob
+//
+// Each word is expanded with the given expand function following brace expansion and
+// prior to glob expansion, as a shell would.
+func globArgs(args []string, dir string, mode GlobMode, expand func(string) (string, error)) ([]string, error) {
+	globbed := []string{}
+
+	for _, a := range args {
+		for _, word := range expandBraces(a) {
+			word, err := expand(word)
+			if err != nil {
+				return nil, err
+			}
+
+			if !hasMeta(word) {
+				globbed = append(globbed, word)
+				continue
+			}
+
+			matches, err := glob(dir, word)
+			if err != nil {
+				return nil, fmt.Errorf("glob: %w in argument %q", err, a)
+			}
+
+			switch {
+			case len(matches) > 0:
+				globbed = append(globbed, matches...)
+			case mode == GlobFail:
+				return nil, fmt.Errorf("glob: %w for pattern %q", ErrNoMatch, word)
+			case mode == GlobLiteral:
+				globbed = append(globbed, word)
+			}
+		}
+	}
+
+	return globbed, nil
+}
+
+// expandBraces returns each word resulting from the brace expressions of the given string,
+// e.g. a{b,c{d,e}} expands to ab acd ace
+//
+// Braces without a top level comma, unbalanced braces and variable references of the
+// form ${...} are preserved literally.
+func expandBraces(s string) []string {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' {
+			continue
+		}
+
+		end, commas := braceEnd(s, i)
+		if end < 0 {
+			return []string{s}
+		}
+
+		// variable references are skipped entirely
+		if i > 0 && s[i-1] == '$' {
+			i = end
+			continue
+		}
+
+		if len(commas) == 0 {
+			continue
+		}
+
+		prefix, suffix := s[:i], s[end+1:]
+		words := []string{}
+		start := i + 1
+
+		for _, c := range append(commas, end) {
+			words = append(words, expandBraces(prefix+s[start:c]+suffix)...)
+			start = c + 1
+		}
+
+		return words
+	}
+
+	return []string{s}
+}
+
+// braceEnd returns the index of the brace closing the brace at the given index of s, or -1
+// if unbalanced, and the index of each comma within the braces at the top level
+func braceEnd(s string, start int) (end int, commas []int) {
+	depth := 0
+
+	for j := start; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+
+			if depth == 0 {
+				return j, commas
+			}
+		case ',':
+			if depth == 1 {
+				commas = append(commas, j)
+			}
+		}
+	}
+
+	return -1, nil
+}
+
+// hasMeta returns whether the given string contains any glob pattern metacharacters
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// glob returns the sorted paths matching the given pattern, relative to the given dir
+// unless the pattern is absolute
+//
+// Patterns are matched per path element as per path.Match, while an element consisting of
+// `**` matches zero or more directories. Hidden files and directories are only matched by
+// elements starting with a '.', and symbolic links to directories are not followed by `**`.
+// A trailing '/' only matches directories.
+func glob(dir, pattern string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	g := &globber{dirOnly: strings.HasSuffix(pattern, "/"), seen: map[string]bool{}}
+
+	root, prefix := dir, ""
+	if filepath.IsAbs(pattern) {
+		root, prefix = string(filepath.Separator), "/"
+	}
+
+	elems := []string{}
+
+	for _, e := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if e != "" {
+			elems = append(elems, e)
+		}
+	}
+
+	// a trailing ** matches every file and directory
+	if len(elems) > 0 && elems[len(elems)-1] == "**" {
+		elems = append(elems, "*")
+	}
+
+	if err := g.walk(root, prefix, elems); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(g.matches)
+
+	return g.matches, nil
+}
+
+// globber matches the elements of a glob pattern, see also: glob
+type globber struct {
+	dirOnly bool
+	matches []string
+	seen    map[string]bool
+}
+
+// walk matches the given pattern elements against the given directory, where rel is the
+// path of the directory as returned in matches
+func (g *globber) walk(dir, rel string, elems []string) error {
+	if len(elems) == 0 {
+		g.match(dir, rel)
+		return nil
+	}
+
+	elem, rest := elems[0], elems[1:]
+
+	switch {
+	case elem == "**":
+		if err := g.walk(dir, rel, rest); err != nil {
+			return err
+		}
+
+		entries, _ := os.ReadDir(dir)
+
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				if err := g.walk(filepath.Join(dir, e.Name()), join(rel, e.Name()), elems); err != nil {
+					return err
+				}
+			}
+		}
+	case !hasMeta(elem):
+		name := unescapeGlob(elem)
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return g.walk(filepath.Join(dir, name), join(rel, name), rest)
+		}
+	default:
+		if _, err := path.Match(elem, ""); err != nil {
+			return err
+		}
+
+		entries, _ := os.ReadDir(dir)
+
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(elem, ".") {
+				continue
+			}
+
+			if ok, _ := path.Match(elem, e.Name()); ok {
+				if err := g.walk(filepath.Join(dir, e.Name()), join(rel, e.Name()), rest); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// match records the given file as a match, if not already matched, where rel is the path
+// of the file as returned in matches
+//
+// The directory the pattern is relative to is never a match.
+func (g *globber) match(file, rel string) {
+	if rel == "" {
+		return
+	}
+
+	if g.dirOnly {
+		info, err := os.Stat(file)
+		if err != nil || !info.IsDir() {
+			return
+		}
+
+		rel += "/"
+	}
+
+	if g.seen[rel] {
+		return
+	}
+
+	g.seen[rel] = true
+	g.matches = append(g.matches, filepath.FromSlash(rel))
+}
+
+// join returns the given relative path joined with the given name
+func join(rel, name string) string {
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		return rel + name
+	}
+
+	return rel + "/" + name
+}
+
+// unescapeGlob returns the given pattern element with backslash escapes removed
+func unescapeGlob(elem string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(elem); i++ {
+		if elem[i] == '\\' && i+1 < len(elem) {
+			i++
+		}
+
+		b.WriteByte(elem[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package cmder_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+// globTree creates a directory tree of empty files for testing glob patterns
+func globTree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for _, f := range []string{
+		"a.go", "b.go", "c.txt", ".hidden.go",
+		"pkg/d.go", "pkg/sub/e.go", "pkg/sub/f.txt",
+		".git/g.go", "build/a/x", "build/b/x",
+	} {
+		path := filepath.Join(dir, f)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// globArgs returns the arguments the given command is executed with in DryRun mode
+func globArgs(t *testing.T, c cmder.Cmder) ([]string, error) {
+	t.Helper()
+
+	logger := &eventLogger{}
+	err := c.Logger(logger).DryRun().Run()
+
+	return logger.events[0].Args[1:], err
+}
+
+func Test_Glob(t *testing.T) {
+	dir := globTree(t)
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"*.go"}, []string{"a.go", "b.go"}},
+		{[]string{"**/*.go"}, []string{"a.go", "b.go", "pkg/d.go", "pkg/sub/e.go"}},
+		{[]string{"./pkg/**/*.txt"}, []string{"./pkg/sub/f.txt"}},
+		{[]string{".*.go"}, []string{".hidden.go"}},
+		{[]string{"*.txt", "?.go"}, []string{"c.txt", "a.go", "b.go"}},
+		{[]string{"build/{a,b}"}, []string{"build/a", "build/b"}},
+		{[]string{"build/*/"}, []string{"build/a/", "build/b/"}},
+		{[]string{"{b,a}.{go,txt}"}, []string{"b.go", "b.txt", "a.go", "a.txt"}},
+		{[]string{"pkg/**"}, []string{"pkg/d.go", "pkg/sub", "pkg/sub/e.go", "pkg/sub/f.txt"}},
+		{[]string{"*.rs", "{x}", "${VAR,x}"}, []string{"*.rs", "{x}", "${VAR,x}"}},
+		{[]string{filepath.Join(dir, "pkg", "*.go")}, []string{filepath.Join(dir, "pkg", "d.go")}},
+	}
+
+	for _, tt := range tests {
+		actual, err := globArgs(t, cmder.New(echo).Args(tt.args...).Dir(dir).Glob())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg := fmt.Sprintf("Expected %v Got %v for %v", tt.expected, actual, tt.args)
+		assert.Equal(t, tt.expected, actual, msg)
+	}
+}
+
+func Test_GlobModes(t *testing.T) {
+	dir := globTree(t)
+
+	actual, err := globArgs(t, cmder.New(echo, "*.rs", "*.txt").Dir(dir).Glob(cmder.GlobNull))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c.txt"}, actual)
+
+	_, err = globArgs(t, cmder.New(echo, "*.rs", "*.txt").Dir(dir).Glob(cmder.GlobFail))
+	assert.True(t, errors.Is(err, cmder.ErrNoMatch), "Expected ErrNoMatch, got: %v", err)
+
+	_, err = globArgs(t, cmder.New(echo, "[").Dir(dir).Glob())
+	assert.Error(t, err, "Expected bad pattern error")
+}
+
+func Test_GlobExpand(t *testing.T) {
+	dir := globTree(t)
+
+	out, err := cmder.New("ls", "${PKG}/*.go").Env("PKG=pkg").Dir(dir).Expand().Glob().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "pkg/d.go\n", string(out))
+}
+
+func Test_GlobLog(t *testing.T) {
+	dir := globTree(t)
+	logger := &eventLogger{}
+
+	err := cmder.New("rm", "*.go").Dir(dir).Glob().Logger(logger).DryRun().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"rm", "*.go"}, logger.events[0].Template)
+	assert.Contains(t, logger.events[0].Message, "[rm *.go] -> [rm a.go b.go]")
+}
+
+func Test_GlobErrorArgs(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, f := range []string{"a.tmp", "b.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the files matched are removed by the command, the error reports the arguments as executed
+	err := cmder.New("sh", "-c", `rm "$@"; exit 1`, "sh", "*.tmp").Dir(dir).Glob().Silent().Run()
+
+	var cmdErr *cmder.Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *cmder.Error. Got %v.", err)
+	}
+
+	assert.Equal(t, []string{"sh", "-c", `rm "$@"; exit 1`, "sh", "a.tmp", "b.tmp"}, cmdErr.Args)
+}
@@ -0,0 +1,207 @@
+package cmder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Group runs a set of commands concurrently, see also: NewGroup
+//
+// By default every command is run to completion and all errors are collected
+// (collect-all). See FailFast to cancel the remaining commands on the first failure.
+type Group struct {
+	cmds     []*cmd
+	ctx      context.Context
+	err      error
+	failFast bool
+	limit    int
+	results  []Result
+}
+
+// Result the result of a single command run as part of a Group
+type Result struct {
+	// Cmd the command which was run
+	Cmd Cmder
+
+	// Duration the time the command took to run
+	Duration time.Duration
+
+	// Err the error returned by the command, if any
+	Err error
+
+	// ExitCode the exit code of the command
+	ExitCode int
+
+	// Status the classification of how the command exited
+	//
+	// Commands which were never started due to FailFast are StatusCanceled.
+	Status Status
+}
+
+// NewGroup returns a new Group for running the given commands concurrently
+//
+// Each command should only be added to a Group once and not be run elsewhere while
+// the Group is running.
+func NewGroup(cmds ...Cmder) *Group {
+	g := &Group{ctx: context.Background()}
+
+	return g.Add(cmds...)
+}
+
+// Add adds the given commands to the Group
+func (g *Group) Add(cmds ...Cmder) *Group {
+	for _, cmdr := range cmds {
+		c, ok := cmdr.(*cmd)
+		if !ok {
+			g.err = fmt.Errorf("group: unsupported Cmder implementation %T", cmdr)
+			continue
+		}
+
+		g.cmds = append(g.cmds, c)
+	}
+
+	return g
+}
+
+// Ctx sets the parent context for every command in the Group
+//
+// Each command retains it's own context (see Cmder.Ctx) and is additionally
+// canceled when the given context is done.
+func (g *Group) Ctx(ctx context.Context) *Group {
+	g.ctx = ctx
+	return g
+}
+
+// FailFast sets the Group to cancel all remaining commands through their context
+// once any command fails. Commands which have yet to start are not run.
+//
+// Run returns the first error encountered.
+func (g *Group) FailFast() *Group {
+	g.failFast = true
+	return g
+}
+
+// Limit sets the maximum number of commands run concurrently
+// a limit less than or equal to zero is unlimited (default)
+func (g *Group) Limit(n int) *Group {
+	g.limit = n
+	return g
+}
+
+// Results returns the Result of each command in the order added to the Group
+// once Run has returned
+func (g *Group) Results() []Result {
+	return g.results
+}
+
+// Run runs every command in the Group, waiting for all of them to complete
+//
+// In collect-all mode (default) the returned error joins the errors of every failed
+// command in the order added. In FailFast mode the first error encountered is returned.
+func (g *Group) Run() error {
+	if g.err != nil {
+		return g.err
+	}
+
+	ctx, cancel := context.WithCancel(g.ctx)
+	defer cancel()
+
+	var (
+		firstErr error
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+
+	g.results = make([]Result, len(g.cmds))
+	sem := make(chan struct{}, g.concurrency())
+
+	for i, c := range g.cmds {
+		i, c := i, c
+
+		sem <- struct{}{}
+
+		if ctx.Err() != nil {
+			<-sem
+
+			g.results[i] = Result{Cmd: c, Err: ErrCanceled, ExitCode: -1, Status: StatusCanceled}
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := g.run(ctx, c)
+			g.results[i] = Result{
+				Cmd:      c,
+				Duration: c.Duration(),
+				Err:      err,
+				ExitCode: c.exitCode,
+				Status:   c.status,
+			}
+
+			if err != nil && g.failFast {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if g.failFast {
+		return firstErr
+	}
+
+	errs := []error{}
+
+	for _, r := range g.results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// concurrency returns the number of commands which may run concurrently
+func (g *Group) concurrency() int {
+	if g.limit <= 0 || g.limit > len(g.cmds) {
+		return len(g.cmds)
+	}
+
+	return g.limit
+}
+
+// run runs the given cmd with a context derived from it's own context which is
+// additionally canceled when the given group context is done
+func (g *Group) run(groupCtx context.Context, c *cmd) error {
+	orig := c.ctx
+
+	ctx, cancel := context.WithCancel(orig)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-groupCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	c.ctx = ctx
+	defer func() { c.ctx = orig }()
+
+	return c.Run()
+}
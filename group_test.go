@@ -0,0 +1,90 @@
+package cmder_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_GroupRun(t *testing.T) {
+	expected := []int{0, 0, 0}
+	group := cmder.NewGroup(
+		cmder.New(echo, "uno"),
+		cmder.New(echo, "dos"),
+	).Add(cmder.New(echo, "tres"))
+
+	err := group.Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	actual := []int{}
+	for _, r := range group.Results() {
+		actual = append(actual, r.ExitCode)
+		assert.Equal(t, cmder.StatusSuccess, r.Status)
+		assert.Positive(t, r.Duration)
+	}
+
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_GroupLimit(t *testing.T) {
+	sleeps := []cmder.Cmder{}
+	for i := 0; i < 4; i++ {
+		sleeps = append(sleeps, cmder.New(sleep, "0.2"))
+	}
+
+	start := time.Now()
+
+	err := cmder.NewGroup(sleeps...).Limit(2).Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	elapsed := time.Since(start)
+	msg := fmt.Sprintf("Expected at least 400ms with a limit of 2. Got %s.", elapsed)
+	assert.GreaterOrEqual(t, elapsed, 400*time.Millisecond, msg)
+}
+
+func Test_GroupCollectAll(t *testing.T) {
+	group := cmder.NewGroup(
+		cmder.New("bash", "-c", "exit 1"),
+		cmder.New(sleep, "0.1"),
+		cmder.New("bash", "-c", "exit 2"),
+	)
+
+	err := group.Run()
+
+	var cmdErr *cmder.Error
+	assert.True(t, errors.As(err, &cmdErr), "Expected joined *cmder.Error. Got %T.", err)
+
+	results := group.Results()
+	assert.Equal(t, 1, results[0].ExitCode)
+	assert.Equal(t, cmder.StatusSuccess, results[1].Status)
+	assert.Equal(t, 2, results[2].ExitCode)
+}
+
+func Test_GroupFailFast(t *testing.T) {
+	group := cmder.NewGroup(
+		cmder.New("bash", "-c", "sleep 0.1; exit 1"),
+		cmder.New(sleep, five),
+		cmder.New(sleep, five),
+	).Limit(2).FailFast()
+
+	start := time.Now()
+	err := group.Run()
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	results := group.Results()
+	assert.Equal(t, cmder.StatusFailed, results[0].Status)
+	assert.Equal(t, cmder.StatusCanceled, results[1].Status)
+	assert.True(t, errors.Is(results[2].Err, cmder.ErrCanceled), "Expected ErrCanceled. Got %v.", results[2].Err)
+}
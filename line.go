@@ -0,0 +1,171 @@
+package cmder
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// MaxLineLength the maximum length in bytes of a line passed to the functions set via
+// OnStdoutLine and OnStderrLine, longer lines are split into lines of MaxLineLength
+//
+// A length less than or equal to zero is unlimited.
+var MaxLineLength = 64 << 10 //nolint:gomnd // 64KiB
+
+// lineWriter implements io.Writer buffering written bytes and invoking fn with each
+// complete line, excluding the trailing newline
+type lineWriter struct {
+	buf []byte
+	fn  func(line []byte)
+	mu  sync.Mutex
+
+	// max the maximum length of a line, longer lines are split, unlimited if <= 0
+	max int
+
+	// cr whether a carriage return not followed by a newline also ends a line, as
+	// written by progress updates. "\r\n" is always a single line ending when set
+	cr bool
+}
+
+// newLineWriter returns a new lineWriter invoking fn with each complete line
+func newLineWriter(fn func(line []byte)) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+// Write implements the io.Writer interface
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		end, next := w.lineEnd()
+		if end < 0 {
+			break
+		}
+
+		w.fn(w.buf[:end])
+		w.buf = w.buf[next:]
+	}
+
+	return len(p), nil
+}
+
+// lineEnd returns the end of the first complete line of the buffer and the start of the
+// following line, or -1 if the buffer contains no complete line
+//
+// A trailing carriage return is not complete until the next write, as it may be followed
+// by a newline.
+func (w *lineWriter) lineEnd() (end, next int) {
+	for i, b := range w.buf {
+		switch {
+		case b == '\n':
+			return i, i + 1
+		case b == '\r' && w.cr:
+			switch {
+			case i+1 == len(w.buf):
+				return -1, -1
+			case w.buf[i+1] == '\n':
+				return i, i + 2 //nolint:gomnd // skip \r\n
+			}
+
+			return i, i + 1
+		case w.max > 0 && i >= w.max:
+			return w.max, w.max
+		}
+	}
+
+	return -1, -1
+}
+
+// Flush invokes fn with any remaining partial line
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cr {
+		w.buf = bytes.TrimSuffix(w.buf, []byte("\r"))
+	}
+
+	if len(w.buf) > 0 {
+		w.fn(w.buf)
+		w.buf = nil
+	}
+}
+
+// syncWriter implements io.Writer serializing writes to the underlying io.Writer
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write implements the io.Writer interface
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.Write(p)
+}
+
+// separateOutput wraps a writer shared by the stdout and stderr of the exec.Cmd, such that
+// it may be written to concurrently once stdout and stderr are wrapped separately, as
+// os/exec only serializes writes to stdout and stderr if they are the same writer
+func (c *cmd) separateOutput() {
+	if c.cmd.Stdout != nil && writerEqual(c.cmd.Stdout, c.cmd.Stderr) {
+		w := &syncWriter{w: c.cmd.Stdout}
+		c.cmd.Stdout = w
+		c.cmd.Stderr = w
+	}
+}
+
+// tee returns a writer duplicating writes to w, if not nil, and each of the given writers
+func tee(w io.Writer, writers ...io.Writer) io.Writer {
+	if w == nil {
+		return io.MultiWriter(writers...)
+	}
+
+	return io.MultiWriter(append([]io.Writer{w}, writers...)...)
+}
+
+// lineOutput tees the stdout and stderr of the exec.Cmd to lineWriters invoking the
+// cmd's OnStdoutLine and OnStderrLine functions
+func (c *cmd) lineOutput() {
+	c.lineWriters = nil
+
+	if c.onStdoutLine == nil && c.onStderrLine == nil {
+		return
+	}
+
+	c.separateOutput()
+
+	if c.onStdoutLine != nil {
+		c.cmd.Stdout = c.teeLines(c.cmd.Stdout, c.onStdoutLine)
+	}
+
+	if c.onStderrLine != nil {
+		c.cmd.Stderr = c.teeLines(c.cmd.Stderr, c.onStderrLine)
+	}
+}
+
+// teeLines returns a writer duplicating writes to w, if not nil, and a new lineWriter
+// invoking fn with each line
+func (c *cmd) teeLines(w io.Writer, fn func(string)) io.Writer {
+	lw := newLineWriter(func(line []byte) {
+		fn(string(line))
+	})
+	lw.cr = true
+	lw.max = MaxLineLength
+
+	c.lineWriters = append(c.lineWriters, lw)
+
+	return tee(w, lw)
+}
+
+// flushLineOutput invokes the cmd's OnStdoutLine and OnStderrLine functions with any
+// remaining partial lines
+func (c *cmd) flushLineOutput() {
+	for _, w := range c.lineWriters {
+		w.Flush()
+	}
+}
@@ -0,0 +1,147 @@
+package cmder
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_lineWriter(t *testing.T) {
+	tests := []struct {
+		writes   []string
+		cr       bool
+		max      int
+		expected []string
+	}{
+		{[]string{"a\nb", "c\n", "d"}, false, 0, []string{"a", "bc", "d"}},
+		{[]string{"10%\r20%\r", "\n100%\r\n"}, true, 0, []string{"10%", "20%", "100%"}},
+		{[]string{"a\r", "\nb\r"}, true, 0, []string{"a", "b"}},
+		{[]string{"a\rb\r\n"}, false, 0, []string{"a\rb\r"}},
+		{[]string{"abcdefg\nabc", "\n"}, false, 3, []string{"abc", "def", "g", "abc"}},
+	}
+
+	for _, tt := range tests {
+		lines := []string{}
+
+		w := newLineWriter(func(line []byte) {
+			lines = append(lines, string(line))
+		})
+		w.cr = tt.cr
+		w.max = tt.max
+
+		for _, s := range tt.writes {
+			_, _ = w.Write([]byte(s))
+		}
+
+		w.Flush()
+
+		msg := fmt.Sprintf("Expected %q Got %q for %q", tt.expected, lines, tt.writes)
+		assert.Equal(t, tt.expected, lines, msg)
+	}
+}
+
+// lineRecorder records the lines passed to it's funcs
+type lineRecorder struct {
+	mu     sync.Mutex
+	stderr []string
+	stdout []string
+}
+
+func (r *lineRecorder) onStderr(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stderr = append(r.stderr, line)
+}
+
+func (r *lineRecorder) onStdout(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stdout = append(r.stdout, line)
+}
+
+func Test_OnLine(t *testing.T) {
+	script := `printf 'a\nb\r\nprogress 1\rprogress 2\r'; printf 'err\n' >&2; printf 'partial'`
+
+	r := &lineRecorder{}
+
+	var stdout, stderr bytes.Buffer
+
+	err := New("sh", "-c", script).
+		OnStdoutLine(r.onStdout).
+		OnStderrLine(r.onStderr).
+		Out(&stdout, &stderr).
+		Silent().
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"a", "b", "progress 1", "progress 2", "partial"}, r.stdout)
+	assert.Equal(t, []string{"err"}, r.stderr)
+	assert.Equal(t, "a\nb\r\nprogress 1\rprogress 2\rpartial", stdout.String(), "Expected stdout to be written")
+	assert.Equal(t, "err\n", stderr.String(), "Expected stderr to be written")
+}
+
+func Test_OnLineStartWait(t *testing.T) {
+	ready := make(chan struct{})
+
+	var once sync.Once
+
+	c := New("sh", "-c", "echo starting; echo ready; sleep 0.1; echo done").
+		OnStdoutLine(func(line string) {
+			if line == "ready" {
+				once.Do(func() { close(ready) })
+			}
+		}).
+		Out(&bytes.Buffer{}).
+		Silent()
+
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	<-ready
+
+	assert.NoError(t, c.Wait())
+}
+
+func Test_OnLineOutput(t *testing.T) {
+	r := &lineRecorder{}
+
+	out, err := New("sh", "-c", "echo a; echo b; echo c >&2; exit 1").
+		OnStdoutLine(r.onStdout).
+		OnStderrLine(r.onStderr).
+		Output()
+
+	assert.Equal(t, "a\nb\n", string(out))
+	assert.Equal(t, []string{"a", "b"}, r.stdout)
+	assert.Equal(t, []string{"c"}, r.stderr)
+
+	var cmdErr *Error
+	if assert.ErrorAs(t, err, &cmdErr) {
+		assert.Equal(t, "c\n", string(cmdErr.Stderr))
+	}
+}
+
+func Test_OnLineCombinedOutput(t *testing.T) {
+	r := &lineRecorder{}
+
+	out, err := New("sh", "-c", "echo a; echo b >&2").
+		OnStdoutLine(r.onStdout).
+		OnStderrLine(r.onStderr).
+		Silent().
+		CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.ElementsMatch(t, []string{"a", "b"}, strings.Fields(string(out)))
+	assert.Equal(t, []string{"a"}, r.stdout)
+	assert.Equal(t, []string{"b"}, r.stderr)
+}
@@ -1,14 +1,25 @@
 package cmder
 
-import "github.com/scottames/cmder/pkg/log"
+import (
+	"fmt"
+	"sync"
 
-var logger log.Logger
+	"github.com/scottames/cmder/pkg/log"
+)
+
+var (
+	logger   log.Logger
+	loggerMu sync.Mutex
+)
 
 // getLogger returns the cmder implementation of the Logger interface
 //
 // If SetLogger called prior to NewLogger the Logger passed to SetLogger will be returned
 // otherwise a new Logger will be returned
 func getLogger() log.Logger {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+
 	if logger != nil {
 		return logger
 	}
@@ -20,5 +31,160 @@ func getLogger() log.Logger {
 
 // SetLogger allows for setting the cmder from an external implementation
 func SetLogger(l log.Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+
 	logger = l
 }
+
+// logEvent logs the given Event with the given logger
+// loggers not implementing log.EventLogger are passed the Event's Message,
+// except for started and completion Events which are only passed to a log.EventLogger
+func logEvent(l log.Logger, e log.Event) {
+	if el, ok := l.(log.EventLogger); ok {
+		el.LogEvent(e)
+		return
+	}
+
+	if e.Complete() || e.Started() {
+		return
+	}
+
+	l.Log(e.Message)
+}
+
+// dryRunEvent returns the given Event in the context of DryRun
+// dryRunKey replaces the action key if not empty
+func dryRunEvent(e log.Event, dryRunKey string) log.Event {
+	e.Color = log.LoggerDryRunColor
+	e.DryRun = true
+
+	if dryRunKey != "" {
+		e.Action = dryRunKey
+	} else {
+		e.Action = log.LoggerDryRunKey + " " + e.Action
+		e.Cols = log.LoggerDryRunCols
+	}
+
+	return e
+}
+
+// dirMsg returns the message representing the given dir in the given color
+func dirMsg(dir string, color log.Color) string {
+	if dir == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(string(color)+" in"+string(log.LoggerClear)+" %s", dir)
+}
+
+// newEvent returns a new log.Event for the cmd with the given action key
+func (c *cmd) newEvent(action string) log.Event {
+	r := c.state()
+
+	e := log.Event{
+		Action: action,
+		Args:   r.args,
+		Color:  log.LoggerColor,
+		Cols:   log.LoggerCols,
+		Dir:    r.dir,
+		Env:    r.delta.set,
+		Shell:  shellCmd(r.dir, envDelta{}, r.args),
+		Unset:  r.delta.unset,
+	}
+
+	e.ShellEnv = shellCmd(r.dir, r.delta, r.args)
+
+	if c.expand || c.glob {
+		e.Template = c.strings
+	}
+
+	if p := c.Process(); p != nil {
+		e.Pid = p.Pid
+	}
+
+	return e
+}
+
+// logCmd logs the cmd with the given action key
+func (c *cmd) logCmd(action string) {
+	if c.silent {
+		return
+	}
+
+	c.logEvent(c.newEvent(action))
+}
+
+// logCmdDryRun logs the cmd with the given action key in the context of DryRun
+// regardless of whether Silent is set
+func (c *cmd) logCmdDryRun(action string) {
+	c.logEvent(dryRunEvent(c.newEvent(action), c.dryRunKey))
+}
+
+// logStarted logs the cmd having started, with it's process id, regardless of whether Silent
+// is set, such that the process id is available to a log.EventLogger
+func (c *cmd) logStarted() {
+	e := c.newEvent(log.LoggerStartedKey)
+	e.Message = fmt.Sprintf("%v started (pid %d)", e.Args, e.Pid)
+
+	logEvent(c.getLogger(), e)
+}
+
+// logComplete logs the completion of the cmd with the given error
+// regardless of whether Silent is set
+func (c *cmd) logComplete(err error) {
+	e := c.newEvent(log.LoggerCompleteKey)
+	e.DryRun = c.isDryRun()
+	e.Duration = c.Duration()
+	e.Err = err
+	e.ExitCode = c.exitCode
+	e.Status = c.status.String()
+	e.Message = fmt.Sprintf("%v %s (exit code %d) after %s", e.Args, c.status, c.exitCode, e.Duration)
+
+	logEvent(c.getLogger(), e)
+}
+
+// logAction logs the given formatted message with the given action key
+// the message is logged in place of the command and environment, regardless of the logger's format
+func (c *cmd) logAction(action, format string, v ...interface{}) {
+	if c.silent {
+		return
+	}
+
+	e := c.newEvent(action)
+	e.Env = nil
+	e.Message = fmt.Sprintf(format, v...)
+	e.Shell = ""
+	e.ShellEnv = ""
+	e.Unset = nil
+
+	logEvent(c.getLogger(), e)
+}
+
+// logEvent logs the given Event with the cmd's logger
+// setting the Message to the command if empty
+func (c *cmd) logEvent(e log.Event) {
+	if e.Message == "" {
+		cmd := fmt.Sprintf("%v", c.strings)
+
+		switch {
+		case c.shell != nil:
+			cmd = c.scriptMsg()
+		case e.Template != nil && !argsEqual(e.Template, e.Args):
+			cmd += fmt.Sprintf(" -> %v", e.Args)
+		}
+
+		e.Message = cmd + dirMsg(e.Dir, e.Color) + c.attemptStr()
+	}
+
+	logEvent(c.getLogger(), e)
+}
+
+// getLogger returns the logger for the cmd, defaulting to the package logger
+func (c *cmd) getLogger() log.Logger {
+	if c.logger == nil {
+		c.logger = getLogger()
+	}
+
+	return c.logger
+}
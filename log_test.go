@@ -0,0 +1,151 @@
+package cmder_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// eventLogger implements the log.EventLogger interface recording each Event
+type eventLogger struct {
+	events []log.Event
+	mu     sync.Mutex
+}
+
+func (l *eventLogger) Log(v ...interface{}) {}
+
+func (l *eventLogger) Logf(format string, v ...interface{}) {}
+
+func (l *eventLogger) LogEvent(e log.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, e)
+}
+
+// actions returns the action of each recorded Event
+func (l *eventLogger) actions() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	actions := []string{}
+	for _, e := range l.events {
+		actions = append(actions, e.Action)
+	}
+
+	return actions
+}
+
+func Test_LogEventActions(t *testing.T) {
+	expected := []string{
+		log.LoggerStartKey,
+		log.LoggerStartedKey,
+		log.LoggerKillKey,
+		log.LoggerWaitKey,
+		log.LoggerCompleteKey,
+		log.LoggerRunKey,
+		log.LoggerStartedKey,
+		log.LoggerCompleteKey,
+		log.LoggerDryRunKey + " " + log.LoggerRunKey,
+	}
+	origKey := log.LoggerKey
+	logger := &eventLogger{}
+
+	cmd := cmder.New(sleep, five).Logger(logger)
+
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = cmd.Kill()
+	_ = cmd.Wait()
+
+	err = cmder.New(echo, foo).Logger(logger).Out(&bytes.Buffer{}).Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = cmder.New(echo, foo).Logger(logger).DryRun().Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	actual := logger.actions()
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+	assert.Equal(t, origKey, log.LoggerKey, "Expected log.LoggerKey not to be modified")
+	assert.Equal(t, []string{echo, foo}, logger.events[5].Args)
+	assert.True(t, logger.events[8].DryRun, "Expected dry run Event")
+
+	assert.Zero(t, logger.events[0].Pid, "Expected no pid prior to starting")
+	assert.Equal(t, *cmd.Pid(), logger.events[1].Pid, "Expected the pid once started")
+
+	killed := logger.events[4]
+	assert.Equal(t, *cmd.Pid(), killed.Pid)
+	assert.Equal(t, -1, killed.ExitCode)
+	assert.Equal(t, cmder.StatusKilled.String(), killed.Status)
+	assert.Error(t, killed.Err)
+
+	started := logger.events[6]
+	assert.Positive(t, started.Pid)
+
+	ran := logger.events[7]
+	assert.Equal(t, 0, ran.ExitCode)
+	assert.Equal(t, started.Pid, ran.Pid)
+	assert.Positive(t, ran.Duration)
+	assert.NoError(t, ran.Err)
+}
+
+func Test_LogConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(4) //nolint:gomnd // one per goroutine below
+
+		go func() {
+			defer wg.Done()
+
+			_ = cmder.New(echo, foo).Out(&bytes.Buffer{}).Run()
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_ = cmder.New(echo, foo).DryRun().Run()
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			cmd := cmder.New(sleep, five)
+			if err := cmd.Start(); err != nil {
+				t.Error(err)
+				return
+			}
+
+			_ = cmd.Kill()
+			_ = cmd.Wait()
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			cmd := cmder.New(echo, foo).Out(&bytes.Buffer{})
+			if err := cmd.Start(); err != nil {
+				t.Error(err)
+				return
+			}
+
+			_ = cmd.Wait()
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,58 @@
+package cmder
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matcher reports whether the given command and arguments match
+type Matcher func(args []string) bool
+
+// Any returns a Matcher matching any command
+func Any() Matcher {
+	return func([]string) bool {
+		return true
+	}
+}
+
+// Exact returns a Matcher matching the given command and arguments exactly
+func Exact(args ...string) Matcher {
+	return func(a []string) bool {
+		return argsEqual(a, args)
+	}
+}
+
+// HasPrefix returns a Matcher matching commands starting with the given command and
+// arguments, e.g. HasPrefix("docker", "push")
+func HasPrefix(args ...string) Matcher {
+	return func(a []string) bool {
+		return len(a) >= len(args) && argsEqual(a[:len(args)], args)
+	}
+}
+
+// Regexp returns a Matcher matching the given regular expression against the command
+// and arguments joined by a single space, e.g. `^git (fetch|pull)\b`
+//
+// Panics if the expression cannot be parsed, see also: regexp.MustCompile
+func Regexp(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+
+	return func(a []string) bool {
+		return re.MatchString(strings.Join(a, " "))
+	}
+}
+
+// argsEqual returns whether the given string slices are equal
+func argsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
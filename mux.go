@@ -0,0 +1,127 @@
+package cmder
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// defaultMux the Mux used by Prefix if none specified
+var defaultMux = NewMux()
+
+// Mux multiplexes the output of concurrently running commands, writing whole lines
+// prefixed with a per-command label and color, similar to docker-compose
+//
+// Colors are assigned to each label from the log package palette and are only
+// written when color is enabled. See also: Cmder.Prefix
+type Mux struct {
+	colors map[string]log.Color
+	mu     sync.Mutex
+	width  int
+}
+
+// NewMux returns a new Mux
+func NewMux() *Mux {
+	return &Mux{colors: map[string]log.Color{}}
+}
+
+// Writer returns a new io.WriteCloser writing each line to w prefixed with the given label
+//
+// Lines are buffered until complete such that lines written by different writers of
+// the Mux are never interleaved. Close writes any remaining partial line.
+func (m *Mux) Writer(label string, w io.Writer) io.WriteCloser {
+	color := m.register(label)
+
+	return &muxWriter{
+		lineWriter: newLineWriter(func(line []byte) {
+			m.writeLine(w, label, color, line)
+		}),
+	}
+}
+
+// register records the given label returning it's color
+func (m *Mux) register(label string) log.Color {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n := stringLen(label); n > m.width {
+		m.width = n
+	}
+
+	if color, ok := m.colors[label]; ok {
+		return color
+	}
+
+	palette := muxPalette()
+	color := palette[len(m.colors)%len(palette)]
+	m.colors[label] = color
+
+	return color
+}
+
+// writeLine writes a single prefixed line to w
+func (m *Mux) writeLine(w io.Writer, label string, color log.Color, line []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "%s%-*s |%s %s\n", color, m.width, label, log.LoggerClear, line)
+}
+
+// prefixOutput wraps the stdout and stderr of the exec.Cmd with writers of the cmd's Mux
+func (c *cmd) prefixOutput() {
+	c.prefixWriters = nil
+
+	if c.prefix == "" {
+		return
+	}
+
+	if c.cmd.Stdout != nil {
+		w := c.mux.Writer(c.prefix, c.cmd.Stdout)
+		c.cmd.Stdout = w
+		c.prefixWriters = append(c.prefixWriters, w)
+	}
+
+	if c.cmd.Stderr != nil {
+		w := c.mux.Writer(c.prefix, c.cmd.Stderr)
+		c.cmd.Stderr = w
+		c.prefixWriters = append(c.prefixWriters, w)
+	}
+}
+
+// flushPrefixOutput writes any remaining partial lines of the cmd's Mux writers
+func (c *cmd) flushPrefixOutput() {
+	for _, w := range c.prefixWriters {
+		_ = w.Close()
+	}
+}
+
+// muxWriter implements io.WriteCloser for a single label of a Mux
+type muxWriter struct {
+	*lineWriter
+}
+
+// Close implements the io.Closer interface flushing any remaining partial line
+func (w *muxWriter) Close() error {
+	w.Flush()
+	return nil
+}
+
+// muxPalette returns the colors assigned to Mux labels in order
+func muxPalette() []log.Color {
+	return []log.Color{
+		log.LoggerTeal,
+		log.LoggerYellow,
+		log.LoggerGreen,
+		log.LoggerMagenta,
+		log.LoggerPurple,
+		log.LoggerRed,
+		log.LoggerWhite,
+	}
+}
+
+// stringLen returns the length of a given string
+func stringLen(s string) int {
+	return len([]rune(s))
+}
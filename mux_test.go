@@ -0,0 +1,77 @@
+package cmder_test
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_MuxWriter(t *testing.T) {
+	expected := "foo | dos\na   | uno\na   | tres\n"
+
+	var buf bytes.Buffer
+
+	mux := cmder.NewMux()
+	a := mux.Writer("a", &buf)
+	f := mux.Writer(foo, &buf)
+
+	fmt.Fprint(a, "u")
+	fmt.Fprint(f, "dos\n")
+	fmt.Fprint(a, "no\ntr")
+	fmt.Fprint(a, "es")
+	a.Close()
+	f.Close()
+
+	actual := buf.String()
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_Prefix(t *testing.T) {
+	expected := "foo | uno\nfoo | dos\n"
+
+	var buf bytes.Buffer
+
+	err := cmder.New("printf", `uno\ndos`).Prefix(foo, cmder.NewMux()).Out(&buf).Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	actual := buf.String()
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_PrefixGroup(t *testing.T) {
+	var buf bytes.Buffer
+
+	mux := cmder.NewMux()
+	cmds := []cmder.Cmder{}
+
+	for _, label := range []string{"a", "b", "c"} {
+		script := fmt.Sprintf("for i in 1 2 3; do printf '%[1]s'; sleep 0.01; echo $i; done", label)
+		cmds = append(cmds, cmder.New("bash", "-c", script).Prefix(label, mux).Out(&buf).Silent())
+	}
+
+	err := cmder.NewGroup(cmds...).Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	sort.Strings(lines)
+
+	expected := []string{
+		"a | a1", "a | a2", "a | a3",
+		"b | b1", "b | b2", "b | b3",
+		"c | c1", "c | c2", "c | c3",
+	}
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, lines)
+	assert.Equal(t, expected, lines, msg)
+}
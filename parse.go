@@ -0,0 +1,253 @@
+package cmder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parse returns a new Cmder for the given shell command string, without invoking a shell
+//
+// The string is split into words following POSIX shell rules: words are separated by
+// unquoted blanks, single quotes preserve their contents literally, double quotes
+// preserve their contents except for backslash escapes of $, `, ", \ and newline, and
+// an unquoted backslash escapes the next character. Leading words of the form
+// VAR=value are added to the environment of the command, see also: Cmder.Env
+//
+//	cmder.Parse(`FOO=bar git commit -m 'a b'`)
+//
+// An error is returned for unsupported shell syntax: the metacharacters | & ; < > ( ) `,
+// comments, unterminated quotes and any unquoted or double quoted '$'. See ParseExpand
+// to expand variables. Glob patterns and '~' are not expanded.
+func Parse(s string) (Cmder, error) {
+	return parse(s, nil)
+}
+
+// ParseExpand returns a new Cmder for the given shell command string, see also: Parse
+//
+// Variables referenced as $VAR, ${VAR}, ${VAR:-default} or ${VAR-default}, unquoted or
+// within double quotes, are expanded from the environment of the command: the environment
+// of the current process and any preceding VAR=value words. Unlike a shell, the result
+// of an expansion is never split into multiple words.
+func ParseExpand(s string) (Cmder, error) {
+	env := envMap(os.Environ())
+
+	return parse(s, func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
+}
+
+// parser splits a shell command string into words, see also: Parse
+type parser struct {
+	env    []string
+	lookup func(string) (string, bool)
+	pos    int
+	s      string
+	words  []string
+}
+
+// parse returns a new Cmder for the given shell command string, expanding variables
+// with the given lookup if not nil
+func parse(s string, lookup func(string) (string, bool)) (Cmder, error) {
+	p := &parser{lookup: lookup, s: s}
+
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("parse: %w at position %d in %q", err, p.pos, s)
+	}
+
+	if len(p.words) == 0 {
+		return nil, fmt.Errorf("parse: no command found in %q", s)
+	}
+
+	return New(p.words...).Env(p.env...), nil
+}
+
+// parse splits the string into words and VAR=value prefixes
+func (p *parser) parse() error {
+	for {
+		p.skipBlanks()
+
+		if p.pos >= len(p.s) {
+			return nil
+		}
+
+		word, assignment, err := p.word()
+		if err != nil {
+			return err
+		}
+
+		if assignment && len(p.words) == 0 {
+			p.assign(word)
+			continue
+		}
+
+		p.words = append(p.words, word)
+	}
+}
+
+// assign adds the given VAR=value to the environment of the command, making it
+// available to subsequent expansions
+func (p *parser) assign(assignment string) {
+	p.env = append(p.env, assignment)
+
+	if p.lookup == nil {
+		return
+	}
+
+	k, v, _ := strings.Cut(assignment, "=")
+	lookup := p.lookup
+
+	p.lookup = func(name string) (string, bool) {
+		if name == k {
+			return v, true
+		}
+
+		return lookup(name)
+	}
+}
+
+// skipBlanks advances past any blanks and escaped newlines
+func (p *parser) skipBlanks() {
+	for p.pos < len(p.s) {
+		switch {
+		case p.s[p.pos] == ' ', p.s[p.pos] == '\t':
+			p.pos++
+		case strings.HasPrefix(p.s[p.pos:], "\\\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// word returns the next word, and whether it is of the form VAR=value with an unquoted
+// valid variable name
+func (p *parser) word() (word string, assignment bool, err error) {
+	var b strings.Builder
+
+	// whether every character of the word so far was unquoted and unescaped
+	plain := true
+
+	if p.s[p.pos] == '#' {
+		return "", false, fmt.Errorf("unsupported comment")
+	}
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+
+		switch {
+		case c == ' ', c == '\t':
+			return b.String(), assignment, nil
+		case c == '=' && plain && !assignment && isName(b.String()):
+			assignment = true
+
+			b.WriteByte(c)
+			p.pos++
+		case c == '\'':
+			plain = false
+
+			if err := p.singleQuoted(&b); err != nil {
+				return "", false, err
+			}
+		case c == '"':
+			plain = false
+
+			if err := p.doubleQuoted(&b); err != nil {
+				return "", false, err
+			}
+		case c == '\\':
+			plain = false
+
+			p.pos++
+			if p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				b.WriteByte(p.s[p.pos])
+			}
+
+			p.pos++
+		case c == '$':
+			plain = false
+
+			if err := p.expand(&b); err != nil {
+				return "", false, err
+			}
+		case strings.IndexByte("|&;<>()`\n", c) >= 0:
+			return "", false, fmt.Errorf("unsupported metacharacter %q", c)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return b.String(), assignment, nil
+}
+
+// singleQuoted writes the contents of the single quoted string at the current position
+func (p *parser) singleQuoted(b *strings.Builder) error {
+	end := strings.IndexByte(p.s[p.pos+1:], '\'')
+	if end < 0 {
+		return fmt.Errorf("unterminated single quote")
+	}
+
+	b.WriteString(p.s[p.pos+1 : p.pos+1+end])
+	p.pos += end + 2 //nolint:gomnd // opening and closing quotes
+
+	return nil
+}
+
+// doubleQuoted writes the contents of the double quoted string at the current position
+func (p *parser) doubleQuoted(b *strings.Builder) error {
+	start := p.pos
+	p.pos++
+
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; c {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			if p.pos+1 < len(p.s) && strings.IndexByte("$`\"\\\n", p.s[p.pos+1]) >= 0 {
+				if p.s[p.pos+1] != '\n' {
+					b.WriteByte(p.s[p.pos+1])
+				}
+
+				p.pos += 2
+
+				continue
+			}
+
+			b.WriteByte(c)
+			p.pos++
+		case '`':
+			return fmt.Errorf("unsupported command substitution")
+		case '$':
+			if err := p.expand(b); err != nil {
+				return err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.pos = start
+
+	return fmt.Errorf("unterminated double quote")
+}
+
+// expand writes the expansion of the variable referenced at the current position
+func (p *parser) expand(b *strings.Builder) error {
+	if p.lookup == nil {
+		return fmt.Errorf("unsupported variable expansion, see ParseExpand")
+	}
+
+	value, n, err := expandVar(p.s[p.pos+1:], p.lookup)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString(value)
+	p.pos += n + 1
+
+	return nil
+}
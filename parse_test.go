@@ -0,0 +1,107 @@
+package cmder_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{`git commit -m 'a b'`, []string{"git", "commit", "-m", "a b"}},
+		{`echo "it's \"quoted\" \$HOME \n" '\n'`, []string{echo, `it's "quoted" $HOME \n`, `\n`}},
+		{`echo a\ b \'c\' ""  ''`, []string{echo, "a b", "'c'", "", ""}},
+		{"echo foo\\\n  bar", []string{echo, "foo", "bar"}},
+		{`echo a=b --opt="x y"z`, []string{echo, "a=b", "--opt=x yz"}},
+		{`echo * ~ a#b`, []string{echo, "*", "~", "a#b"}},
+	}
+
+	for _, tt := range tests {
+		c, err := cmder.Parse(tt.s)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		actual := c.Args().ShellString()
+		expected := cmder.New(tt.expected...).ShellString()
+		msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+		assert.Equal(t, expected, actual, msg)
+	}
+}
+
+func Test_ParseEnv(t *testing.T) {
+	expected := `FOO=bar BAR='a b' printenv FOO`
+
+	c, err := cmder.Parse(`FOO=bar BAR="a b" printenv FOO`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := c.ShellString()
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+
+	out, err := c.Silent().Output()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, "bar", strings.TrimSpace(string(out)))
+}
+
+func Test_ParseExpand(t *testing.T) {
+	t.Setenv("CMDER_PARSE", "parsed")
+	t.Setenv("CMDER_EMPTY", "")
+
+	c, err := cmder.ParseExpand(
+		`LOCAL=local echo $CMDER_PARSE "${CMDER_PARSE}-x" '$CMDER_PARSE' ${CMDER_EMPTY:-default} ` +
+			`"${CMDER_EMPTY-default}" "$CMDER_UNSET""foo bar" $LOCAL $ /`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{echo, "parsed", "parsed-x", "$CMDER_PARSE", "default", "", "foo bar", "local", "$", "/"}
+	expected := "LOCAL=local " + cmder.New(args...).ShellString()
+	actual := c.ShellString()
+
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_ParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"FOO=bar",
+		"echo foo | wc -l",
+		"echo foo; rm -rf /",
+		"echo foo && bar",
+		"echo foo > out",
+		"echo $(whoami)",
+		"echo `whoami`",
+		`echo "$(whoami)"`,
+		"echo $HOME",
+		"echo 'unterminated",
+		`echo "unterminated`,
+		"echo # comment",
+		"echo foo\nrm -rf /",
+	}
+
+	for _, s := range tests {
+		_, err := cmder.Parse(s)
+		assert.Error(t, err, "Expected error parsing %q", s)
+	}
+
+	for _, s := range []string{"echo $(whoami)", "echo ${unterminated", "echo $1", "echo ${A:=b}"} {
+		_, err := cmder.ParseExpand(s)
+		assert.Error(t, err, "Expected error parsing %q", s)
+	}
+}
@@ -0,0 +1,8 @@
+package cmder
+
+import "syscall"
+
+// setPdeathsig sets the signal the process will receive when it's parent dies
+func setPdeathsig(attr *syscall.SysProcAttr, sig syscall.Signal) {
+	attr.Pdeathsig = sig
+}
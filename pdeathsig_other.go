@@ -0,0 +1,8 @@
+//go:build unix && !linux
+
+package cmder
+
+import "syscall"
+
+// setPdeathsig is a no-op as the parent death signal is only supported on Linux
+func setPdeathsig(*syscall.SysProcAttr, syscall.Signal) {}
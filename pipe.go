@@ -0,0 +1,478 @@
+package cmder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// Pipeline a series of commands where the stdout of each command is connected
+// to the stdin of the next, as a shell would with `a | b | c`
+//
+// Each stage is executed as its own process and retains its own ExitCode and
+// Duration which can be inspected via Cmds once the Pipeline has completed.
+type Pipeline struct {
+	cmds      []*cmd
+	dryRun    bool
+	dryRunKey string
+	err       error
+	logger    log.Logger
+	silent    bool
+}
+
+// Pipe returns a new Pipeline connecting the given commands
+//
+// The stdin of the first command and the stdout of the last command are used
+// as the stdin and stdout of the Pipeline. The stderr of each stage is left as
+// configured on the individual command.
+//
+// Pipelines use pipefail semantics: the Pipeline fails if any stage fails and
+// the error returned is that of the last (right-most) stage to fail.
+func Pipe(cmds ...Cmder) *Pipeline {
+	p := &Pipeline{}
+
+	if len(cmds) == 0 {
+		p.err = fmt.Errorf("pipe: at least one command expected")
+		return p
+	}
+
+	for _, cmdr := range cmds {
+		c, ok := cmdr.(*cmd)
+		if !ok {
+			p.err = fmt.Errorf("pipe: unsupported Cmder implementation %T", cmdr)
+			return p
+		}
+
+		p.cmds = append(p.cmds, c)
+	}
+
+	return p
+}
+
+// Cmds returns the commands making up each stage of the Pipeline
+func (p *Pipeline) Cmds() []Cmder {
+	cmds := make([]Cmder, 0, len(p.cmds))
+	for _, c := range p.cmds {
+		cmds = append(cmds, c)
+	}
+
+	return cmds
+}
+
+// Complete returns a boolean value as to whether every stage of the Pipeline
+// has completed it's execution
+func (p *Pipeline) Complete() bool {
+	if len(p.cmds) == 0 {
+		return false
+	}
+
+	for _, c := range p.cmds {
+		if !c.complete {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DryRun sets the Pipeline to run in DryRun mode (log and skip execution of every stage)
+//
+// Option to pass one or more strings to replace the dryrun action key
+// defaults to LoggerDryRunKey
+func (p *Pipeline) DryRun(keys ...string) *Pipeline {
+	p.dryRunKey = strings.Join(keys, " ")
+	p.dryRun = true
+
+	return p
+}
+
+// Duration returns the duration in time.Duration from the start of the first
+// stage until the last stage to exit
+func (p *Pipeline) Duration() time.Duration {
+	var start, end time.Time
+
+	for _, c := range p.cmds {
+		if start.IsZero() || (!c.start.IsZero() && c.start.Before(start)) {
+			start = c.start
+		}
+
+		if c.end.After(end) {
+			end = c.end
+		}
+	}
+
+	return end.Sub(start)
+}
+
+// ExitCode returns the exit code of the Pipeline following pipefail semantics:
+// the exit code of the last (right-most) stage to exit non-zero, or zero if all
+// stages exited successfully
+func (p *Pipeline) ExitCode() int {
+	for i := len(p.cmds) - 1; i >= 0; i-- {
+		if code := p.cmds[i].exitCode; code != 0 {
+			return code
+		}
+	}
+
+	return 0
+}
+
+// ExitCodes returns the exit code of each stage of the Pipeline in order
+func (p *Pipeline) ExitCodes() []int {
+	codes := make([]int, 0, len(p.cmds))
+	for _, c := range p.cmds {
+		codes = append(codes, c.exitCode)
+	}
+
+	return codes
+}
+
+// LogCmd will print the Pipeline that is to be executed, in the form `[a | b | c]`
+// Included in Run if Silent unset
+func (p *Pipeline) LogCmd() {
+	p.logCmd(log.LoggerRunKey)
+}
+
+// Logger allows setting an external logger for the Pipeline.
+// Defaults to the logger of the first stage, if set.
+func (p *Pipeline) Logger(l log.Logger) *Pipeline {
+	p.logger = l
+	return p
+}
+
+// Output runs the Pipeline and returns the stdout of the last stage, along with any
+// error, as per Cmder.Output
+func (p *Pipeline) Output() ([]byte, error) {
+	p.Silent()
+
+	var b bytes.Buffer
+
+	err := p.run(log.LoggerOutputKey, &b, p.lastStderr())
+
+	return b.Bytes(), err
+}
+
+// Run starts every stage of the Pipeline and waits for all of them to complete
+//
+// Optionally one or two io.Writer may be passed to Run where
+// the first being stdout and the second being stderr of the last stage.
+// If only one specified it will be connected to the last stage's stdout and stderr.
+func (p *Pipeline) Run(w ...io.Writer) error {
+	return p.run(log.LoggerRunKey, w...)
+}
+
+// Silent will set Run to not print the Pipeline prior to execution
+func (p *Pipeline) Silent() *Pipeline {
+	p.silent = true
+	return p
+}
+
+// ShellString returns the Pipeline as a POSIX shell pipeline suitable for copy-paste,
+// see also: Cmder.ShellString
+//
+// Stages with a working directory differing from the first stage are run in a subshell.
+func (p *Pipeline) ShellString() string {
+	return p.shellString(true)
+}
+
+// shellString returns the Pipeline as a POSIX shell pipeline, including the environment
+// of each stage if env, see also: ShellString
+func (p *Pipeline) shellString(env bool) string {
+	stages := make([]string, 0, len(p.cmds))
+	dir := ""
+
+	for i, c := range p.cmds {
+		r := c.state()
+		args, cdir := r.args, r.dir
+
+		if i == 0 {
+			dir = cdir
+		}
+
+		delta := envDelta{}
+		if env {
+			delta = c.envDelta()
+		}
+
+		stages = append(stages, pipelineStage(shellCmd("", delta, args), cdir, dir))
+	}
+
+	s := strings.Join(stages, " | ")
+	if dir != "" {
+		s = "cd " + shellQuote(dir) + " && " + s
+	}
+
+	return s
+}
+
+// pipelineStage returns the given stage of a pipeline run in a subshell with a `cd` to the
+// given working directory of the stage, if differing from the pipeline's working directory
+func pipelineStage(stage, dir, pipelineDir string) string {
+	if dir == "" || dir == pipelineDir {
+		return stage
+	}
+
+	return "(cd " + shellQuote(dir) + " && " + stage + ")"
+}
+
+// String returns a human-readable description of the Pipeline
+// It is intended only for debugging.
+func (p *Pipeline) String() string {
+	s := make([]string, 0, len(p.cmds))
+	for _, c := range p.cmds {
+		s = append(s, c.String())
+	}
+
+	return strings.Join(s, " | ")
+}
+
+// run executes the Pipeline logging the given action key
+func (p *Pipeline) run(key string, w ...io.Writer) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	last := len(p.cmds) - 1
+
+	for i, c := range p.cmds {
+		if i == last {
+			c.buildExec(w...)
+		} else {
+			c.buildExec()
+		}
+	}
+
+	recordCmds(p.cmds...)
+
+	if p.isDryRun() {
+		logEvent(p.getLogger(), dryRunEvent(p.newEvent(key), p.dryRunKey))
+		return p.simulate()
+	}
+
+	p.logCmd(key)
+
+	for _, c := range p.cmds {
+		if c.buildErr != nil {
+			c.start = time.Now()
+			return c.endState(c.buildErr)
+		}
+	}
+
+	native := p.native()
+
+	// parent's copies of the pipe ends, closed once handed to a started stage
+	pipes := make([]io.Closer, 0, last*2) //nolint:gomnd // reader and writer per pipe
+
+	// pipe ends closed once each stage has completed, when not executed by os/exec
+	stageEnds := make([][]io.Closer, len(p.cmds))
+
+	for i := 0; i < last; i++ {
+		r, pw, err := newPipe(native)
+		if err != nil {
+			closeAll(pipes)
+			return err
+		}
+
+		p.cmds[i].cmd.Stdout = pw
+		p.cmds[i+1].cmd.Stdin = r
+
+		if native {
+			pipes = append(pipes, r, pw)
+		} else {
+			stageEnds[i] = append(stageEnds[i], pw)
+			stageEnds[i+1] = append(stageEnds[i+1], r)
+		}
+	}
+
+	for i, c := range p.cmds {
+		c.start = time.Now()
+
+		if err := c.getExecutor().Start(c.cmd); err != nil {
+			closeAll(pipes)
+			p.abort(i)
+
+			for _, ends := range stageEnds {
+				closeAll(ends)
+			}
+
+			return c.endState(err)
+		}
+
+		c.setProcess()
+		c.logStarted()
+	}
+
+	closeAll(pipes)
+
+	var err error
+
+	for i, c := range p.cmds {
+		e := c.endState(c.wait())
+		closeAll(stageEnds[i])
+
+		if e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// simulate simulates each stage of the Pipeline in DryRun mode, see also: OnDryRun
+// the stdout of each stage other than the last is discarded
+func (p *Pipeline) simulate() error {
+	var err error
+
+	last := len(p.cmds) - 1
+
+	for i, c := range p.cmds {
+		stdout := io.Discard
+		if i == last {
+			stdout = c.cmd.Stdout
+		}
+
+		if e := c.simulate(stdout, c.cmd.Stderr); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// abort kills and waits for the first n stages which have already been started
+func (p *Pipeline) abort(n int) {
+	for _, c := range p.cmds[:n] {
+		_ = c.signalProcess(os.Kill)
+		_ = c.endState(c.wait())
+	}
+}
+
+// getLogger returns the logger for the Pipeline
+func (p *Pipeline) getLogger() log.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+
+	if len(p.cmds) > 0 && p.cmds[0].logger != nil {
+		return p.cmds[0].logger
+	}
+
+	return getLogger()
+}
+
+// isDryRun returns whether dryRun is set in the scope of the Pipeline, any of
+// it's stages, or globally
+func (p *Pipeline) isDryRun() bool {
+	if dryRun || p.dryRun {
+		return true
+	}
+
+	for _, c := range p.cmds {
+		if c.dryRun {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastStderr returns the stderr configured for the last stage of the Pipeline
+func (p *Pipeline) lastStderr() io.Writer {
+	if len(p.cmds) == 0 {
+		return nil
+	}
+
+	return p.cmds[len(p.cmds)-1].stderr
+}
+
+// logCmd logs the Pipeline with the given action key
+func (p *Pipeline) logCmd(action string) {
+	if p.silent || len(p.cmds) == 0 {
+		return
+	}
+
+	logEvent(p.getLogger(), p.newEvent(action))
+}
+
+// newEvent returns a new log.Event for the Pipeline with the given action key
+func (p *Pipeline) newEvent(action string) log.Event {
+	e := log.Event{
+		Action: action,
+		Color:  log.LoggerColor,
+		Cols:   log.LoggerCols,
+	}
+
+	stages := make([]string, 0, len(p.cmds))
+	dirs := []string{}
+
+	for _, c := range p.cmds {
+		r := c.state()
+		args, dir := r.args, r.dir
+
+		e.Pipeline = append(e.Pipeline, args)
+		stages = append(stages, strings.Join(args, " "))
+
+		if dir != "" && !contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if len(dirs) > 0 {
+		e.Dir = dirs[0]
+	}
+
+	e.Message = "[" + strings.Join(stages, " | ") + "]" + dirMsg(strings.Join(dirs, ", "), e.Color)
+	e.Shell = p.shellString(false)
+	e.ShellEnv = p.shellString(true)
+
+	return e
+}
+
+// native returns whether every stage of the Pipeline is executed by os/exec
+func (p *Pipeline) native() bool {
+	for _, c := range p.cmds {
+		if _, ok := c.getExecutor().(osExecutor); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newPipe returns a new pipe connecting two stages of a Pipeline
+//
+// stages executed by os/exec are connected with an os.Pipe, allowing the processes
+// to communicate directly, otherwise an io.Pipe is used
+func newPipe(native bool) (io.ReadCloser, io.WriteCloser, error) {
+	if native {
+		r, w, err := os.Pipe()
+		return r, w, err
+	}
+
+	r, w := io.Pipe()
+
+	return r, w, nil
+}
+
+// closeAll closes all of the given closers ignoring any errors
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
+
+// contains returns whether the given slice contains the given string
+func contains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package cmder_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_PipeOutput(t *testing.T) {
+	expected := "f00\n"
+
+	out, err := cmder.Pipe(
+		cmder.New(echo, foo),
+		cmder.New("tr", "o", "0"),
+		cmder.New(cat),
+	).Output()
+	if err != nil {
+		t.Error(err)
+	}
+
+	actual := string(out)
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_PipeFail(t *testing.T) {
+	expected := []int{3, 0}
+	pipeline := cmder.Pipe(
+		cmder.New("bash", "-c", "echo foo; exit 3"),
+		cmder.New(cat),
+	)
+
+	err := pipeline.Run()
+	if err == nil {
+		t.Error("Expected error from failing stage. Got nil.")
+	}
+
+	actual := pipeline.ExitCodes()
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+	assert.Equal(t, 3, pipeline.ExitCode())
+	assert.True(t, pipeline.Complete())
+}
+
+func Test_PipeOutputFail(t *testing.T) {
+	out, err := cmder.Pipe(
+		cmder.New("bash", "-c", "echo foo; exit 3"),
+		cmder.New("tr", "o", "0"),
+	).Output()
+
+	assert.Error(t, err)
+	assert.Equal(t, "f00\n", string(out), "Expected the output of the last stage with the error")
+}
+
+func Test_PipeDryRun(t *testing.T) {
+	pipeline := cmder.Pipe(cmder.New(echo, foo), cmder.New(cat)).DryRun()
+
+	out, err := pipeline.Output()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Nil(t, out)
+	assert.False(t, pipeline.Complete())
+
+	for _, c := range pipeline.Cmds() {
+		assert.Nil(t, c.Process())
+	}
+}
+
+func Test_PipeLogCmd(t *testing.T) {
+	expected := "[echo foo | tr o 0 | cat]"
+
+	cmder.Pipe(
+		cmder.New(echo, foo),
+		cmder.New("tr", "o", "0"),
+		cmder.New(cat),
+	).Logger(testLogger{}).LogCmd()
+
+	actual := logCmdStr
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_PipeLogShell(t *testing.T) {
+	logger := &eventLogger{}
+
+	err := cmder.Pipe(
+		cmder.New(echo, foo).Env("SECRET=value"),
+		cmder.New(cat),
+	).Logger(logger).DryRun().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := logger.events[0]
+	assert.Equal(t, "echo foo | cat", e.Shell)
+	assert.Equal(t, "SECRET=value echo foo | cat", e.ShellEnv)
+}
@@ -29,13 +29,100 @@ type Logger interface {
 	Logf(format string, v ...interface{})
 }
 
+// EventLogger extends the Logger interface with the ability to log an Event
+//
+// Cmder passes each Event describing the action of a command to loggers implementing
+// EventLogger, allowing the action key, color and columns to be provided per log call
+// rather than via the package level Logger* variables. Loggers only implementing Logger
+// are passed the Event's Message via Log.
+type EventLogger interface {
+	Logger
+
+	// LogEvent inserts a log entry for the given Event
+	LogEvent(e Event)
+}
+
+// Event a single log entry describing the action of a command
+type Event struct {
+	// Action the action of the command, e.g. LoggerRunKey
+	Action string
+
+	// Args the command and arguments, as executed
+	Args []string
+
+	// Color the color used to print the Action
+	Color Color
+
+	// Cols the right justified columns the Action will be padded
+	Cols string
+
+	// Dir the working directory of the command
+	Dir string
+
+	// DryRun whether the command is run in DryRun mode, and therefore not executed
+	DryRun bool
+
+	// Duration the time the command took to run, set once the command has completed
+	Duration time.Duration
+
+	// Env the environment variables of the command which differ from the calling process,
+	// in the form "key=value"
+	Env []string
+
+	// Err the error returned by the command, if any, set once the command has completed
+	Err error
+
+	// ExitCode the exit code of the command, set once the command has completed
+	ExitCode int
+
+	// Message the human readable message describing the Event
+	Message string
+
+	// Pid the process id of the command, zero if the process has not been started
+	Pid int
+
+	// Pipeline the command and arguments of each stage if the Event describes a Pipeline
+	Pipeline [][]string
+
+	// Shell the command as a POSIX shell command suitable for copy-paste, including
+	// the working directory, excluding environment variables, see also: ShellEnv
+	Shell string
+
+	// ShellEnv the command as a POSIX shell command suitable for copy-paste, including
+	// the working directory and environment variables of the command
+	//
+	// Note the values of environment variables, which may be sensitive, are included.
+	// ShellEnv should only be logged when the logging of environment variables is enabled.
+	ShellEnv string
+
+	// Status the classification of how the command exited, set once the command has completed
+	Status string
+
+	// Template the command and arguments prior to the expansion of variable references
+	// and glob patterns, set if the command's arguments are expanded, see also: Args
+	Template []string
+
+	// Unset the keys of the environment variables of the calling process which are
+	// removed from the environment of the command
+	Unset []string
+}
+
+// Complete returns whether the Event describes the completion of a command
+func (e Event) Complete() bool {
+	return e.Action == LoggerCompleteKey
+}
+
+// Started returns whether the Event describes a command having started
+func (e Event) Started() bool {
+	return e.Action == LoggerStartedKey
+}
+
 // Color a string alias for logging colors
 type Color string
 
 var (
-	// LoggerKey the key used to when logging the command
-	// if the default logger is used and Silent is not specified
-	// represents the action of the command
+	// LoggerKey the default key used when logging, if the default logger is used and
+	// the key is not otherwise specified by the logged Event or Key
 	LoggerKey = LoggerRunKey
 
 	// LoggerCols specifies the right justified columns the LoggerKey will be padded
@@ -53,6 +140,10 @@ var (
 	// DryRun is invoked
 	LoggerDryRunCols = "10"
 
+	// LoggerCompleteKey the key used to represent the completion of a command
+	// only logged by loggers implementing EventLogger, ignored by the default logger
+	LoggerCompleteKey = "complete"
+
 	// LoggerDryRunKey the key used to represent the action of the command when DryRun is invoked
 	LoggerDryRunKey = "dry"
 
@@ -62,12 +153,19 @@ var (
 	// LoggerOutputKey the key used to represent the action of the command when Output is invoked
 	LoggerOutputKey = "output"
 
+	// LoggerRetryKey the key used to represent the action of the command when an attempt is retried
+	LoggerRetryKey = "retry"
+
 	// LoggerRunKey the key used to represent the action of the command when Run is invoked
 	LoggerRunKey = "run"
 
 	// LoggerStartKey the key used to represent the action of the command when Start is invoked
 	LoggerStartKey = "start"
 
+	// LoggerStartedKey the key used to represent a command having started, with it's Pid
+	// only logged by loggers implementing EventLogger, ignored by the default logger
+	LoggerStartedKey = "started"
+
 	// LoggerWaitKey the key used to represent the action of the command when Start is invoked
 	LoggerWaitKey = "wait"
 
@@ -142,27 +240,56 @@ func init() {
 	}
 }
 
-// New returns a new logger instance which implements the Logger interface
+// New returns a new logger instance which implements the Logger and EventLogger interfaces
 func New() *logger { //nolint:revive // the intention is to leverage the methods and interface
-	return &logger{key: LoggerKey, color: LoggerColor}
+	return &logger{}
 }
 
 // logger implements the Logger interface and adds additional functionality
 type logger struct {
 	color       Color
+	colorSet    bool
+	env         bool
 	key         string
+	keySet      bool
 	noTimestamp bool
+	shell       bool
 }
 
 // Key sets the logger key for the given logger instance
+// overriding the action of any logged Event
 func (l *logger) Key(k string) *logger {
 	l.key = k
+	l.keySet = true
+
 	return l
 }
 
-// Color sets the logger key for the given logger instance
+// Color sets the logger color for the given logger instance
+// overriding the color of any logged Event
 func (l *logger) Color(lc Color) *logger {
 	l.color = lc
+	l.colorSet = true
+
+	return l
+}
+
+// EnvDelta sets the logger to print the environment variables of each command which differ
+// from the calling process, e.g. `[echo foo] with FOO=bar without HOME`
+//
+// Note the values of environment variables, which may be sensitive, are printed.
+func (l *logger) EnvDelta() *logger {
+	l.env = true
+	return l
+}
+
+// ShellFormat sets the logger to print commands as POSIX shell commands suitable for
+// copy-paste, e.g. `cd /tmp && echo 'hello world'`, rather than `[echo hello world]`
+//
+// Environment variables are only included, e.g. `cd /tmp && FOO=bar echo 'hello world'`,
+// if EnvDelta is also set.
+func (l *logger) ShellFormat() *logger {
+	l.shell = true
 	return l
 }
 
@@ -174,13 +301,15 @@ func (l *logger) WithoutTimestamp() *logger {
 
 // Logf implements the Logger interface
 func (l logger) Logf(format string, v ...interface{}) {
-	s := l.prependStr()
+	s := l.prependStr(l.getKey(""), l.getColor(""), LoggerCols)
 	timestamp := l.timestamp(string(LoggerDarkGrey))
 	fmt.Printf(s+format+timestamp+"\n", v...)
 }
 
 // splitArgsToNewLine returns a new string formatted as a shell command as if being executed
 // on multiple lines - padding between the [] of the command (slice) to be printed
+//
+// The command is only broken on argument boundaries, quoted arguments are never split.
 func splitArgsToNewLine(s string) string {
 	var result string
 
@@ -190,25 +319,25 @@ func splitArgsToNewLine(s string) string {
 	)
 
 	newArgs := []string{}
-	args := strings.Split(s, " ")
+	args := splitWords(s)
 
 	for _, w := range args {
 		if strings.HasPrefix(w, bBracket) {
 			w = strings.Replace(w, bBracket, bBracket+"\n\n ", 1)
 		}
 
-		if strings.HasSuffix(w, eBracket) {
+		switch {
+		case strings.HasSuffix(w, eBracket):
 			i := strings.LastIndex(w, eBracket)
 			w = w[:i] + strings.Replace(w[i:], eBracket, "\n\n"+eBracket, 1)
-		}
-
-		if strings.Contains(w, eBracket+string(LoggerColor)) {
+		case LoggerColor != "" && strings.Contains(w, eBracket+string(LoggerColor)):
 			w = strings.Replace(w, eBracket+string(LoggerColor), "\n\n"+eBracket+string(LoggerColor), 1)
 		}
 
-		if strings.HasPrefix(w, "-") {
+		switch {
+		case strings.HasPrefix(w, "-"), w == "|", w == "&&":
 			newArgs = append(newArgs, "\\\n   "+w)
-		} else {
+		default:
 			newArgs = append(newArgs, w)
 		}
 	}
@@ -218,6 +347,64 @@ func splitArgsToNewLine(s string) string {
 	return result
 }
 
+// splitWords splits the given string on spaces outside of single or double quotes
+//
+// Quotes and backslash escapes are retained in each word. If the string contains an
+// unterminated quote, e.g. an apostrophe of an unquoted argument, it is split on every space.
+func splitWords(s string) []string {
+	words := []string{}
+
+	var (
+		b       strings.Builder
+		escaped bool
+		quote   rune
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ' ':
+			words = append(words, b.String())
+			b.Reset()
+
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	if quote != 0 || escaped {
+		return strings.Split(s, " ")
+	}
+
+	return append(words, b.String())
+}
+
+// envMsg returns the message representing the environment variables of the given Event
+// which differ from the calling process, with the given color
+func envMsg(e Event, color Color) string {
+	var b strings.Builder
+
+	if len(e.Env) > 0 {
+		b.WriteString(string(color) + " with" + string(LoggerClear) + " " + strings.Join(e.Env, " "))
+	}
+
+	if len(e.Unset) > 0 {
+		b.WriteString(string(color) + " without" + string(LoggerClear) + " " + strings.Join(e.Unset, " "))
+	}
+
+	return b.String()
+}
+
 // stringLen returns the length of a given string
 func stringLen(s string) int {
 	return len([]rune(s))
@@ -225,9 +412,40 @@ func stringLen(s string) int {
 
 // Log implements the Logger interface
 func (l logger) Log(v ...interface{}) {
-	s := l.prependStr()
+	l.log(l.prependStr(l.getKey(""), l.getColor(""), LoggerCols), fmt.Sprintf("%v", v...))
+}
+
+// LogEvent implements the EventLogger interface
+//
+// The Event's Action, Color and Cols are used unless overridden by Key or Color.
+// Events describing a command having started or completed are ignored.
+func (l logger) LogEvent(e Event) {
+	if e.Complete() || e.Started() {
+		return
+	}
+
+	cols := e.Cols
+	if cols == "" {
+		cols = LoggerCols
+	}
+
+	msg := e.Message
+
+	switch {
+	case l.shell && l.env && e.ShellEnv != "":
+		msg = e.ShellEnv
+	case l.shell && e.Shell != "":
+		msg = e.Shell
+	case l.env:
+		msg += envMsg(e, l.getColor(e.Color))
+	}
+
+	l.log(l.prependStr(l.getKey(e.Action), l.getColor(e.Color), cols), msg)
+}
+
+// log prints the given message prepended with the given string
+func (l logger) log(s, msg string) {
 	timestamp := l.timestamp(string(LoggerDarkGrey))
-	msg := fmt.Sprintf("%v", v...)
 
 	termWidth, _, err := term.GetSize(int(os.Stdin.Fd()))
 	if err == nil {
@@ -240,14 +458,13 @@ func (l logger) Log(v ...interface{}) {
 	fmt.Printf(s+"%s"+timestamp+"\n", msg)
 }
 
-func (l logger) prependStr() string {
-	key := l.getKey()
+func (l logger) prependStr(key string, color Color, cols string) string {
 	colonColor := string(LoggerDarkGrey)
 
 	return fmt.Sprintf(
-		string(l.getColor())+
+		string(color)+
 			"%"+
-			LoggerCols+
+			cols+
 			"s"+
 			colonColor+
 			" : "+
@@ -266,19 +483,31 @@ func (l logger) timestamp(color string) string {
 	return color + " : " + now + string(LoggerClear)
 }
 
-func (l logger) getColor() Color {
-	if l.color != LoggerColor {
+// getColor returns the color for the logger, in order of precedence: the color set via Color,
+// the given color (of an Event) or LoggerColor
+func (l logger) getColor(c Color) Color {
+	if l.colorSet {
 		return l.color
 	}
 
+	if c != "" {
+		return c
+	}
+
 	return LoggerColor
 }
 
-func (l logger) getKey() string {
-	if l.key != LoggerKey {
+// getKey returns the key for the logger, in order of precedence: the key set via Key,
+// the given key (action of an Event) or LoggerKey
+func (l logger) getKey(k string) string {
+	if l.keySet {
 		return l.key
 	}
 
+	if k != "" {
+		return k
+	}
+
 	return LoggerKey
 }
 
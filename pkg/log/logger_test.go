@@ -0,0 +1,59 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitArgsToNewLine(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{
+			"[echo -n foo]",
+			"[\n\n echo \\\n   -n foo\n\n]",
+		},
+		{
+			"cd /tmp && echo -n 'a -b c' \"d e\" | wc -l",
+			"cd /tmp \\\n   && echo \\\n   -n 'a -b c' \"d e\" \\\n   | wc \\\n   -l",
+		},
+		{
+			"[echo it's -n]",
+			"[\n\n echo it's \\\n   -n\n\n]",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := splitArgsToNewLine(tt.s)
+		msg := fmt.Sprintf("Expected %q Got %q", tt.expected, actual)
+		assert.Equal(t, tt.expected, actual, msg)
+	}
+}
+
+func Test_splitWords(t *testing.T) {
+	expected := []string{"echo", `'a b'`, `"c \" d"`, `e\ f`, "'it'\\''s'"}
+	actual := splitWords(`echo 'a b' "c \" d" e\ f 'it'\''s'`)
+
+	msg := fmt.Sprintf("Expected %v Got %v", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_envMsg(t *testing.T) {
+	tests := []struct {
+		e        Event
+		expected string
+	}{
+		{Event{}, ""},
+		{Event{Env: []string{"FOO=bar", "A=1"}}, " with FOO=bar A=1"},
+		{Event{Env: []string{"FOO=bar"}, Unset: []string{"HOME"}}, " with FOO=bar without HOME"},
+	}
+
+	for _, tt := range tests {
+		actual := envMsg(tt.e, "")
+		msg := fmt.Sprintf("Expected '%s' Got '%s'", tt.expected, actual)
+		assert.Equal(t, tt.expected, actual, msg)
+	}
+}
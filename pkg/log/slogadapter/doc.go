@@ -0,0 +1,10 @@
+// Package slogadapter provides a cmder Logger writing structured log records
+// to a log/slog Logger (Go 1.21+)
+//
+// Each command lifecycle step (start, run, output, wait, kill, dry run and
+// completion) is logged as a record with the command's arguments, directory,
+// process id, exit code, duration and dry-run flag as attributes:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+//	cmder.SetLogger(slogadapter.New(logger))
+package slogadapter
@@ -0,0 +1,185 @@
+//go:build go1.21
+
+package slogadapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// Attribute keys used for each Event logged
+const (
+	// ActionKey the key of the action of the command, e.g. log.LoggerRunKey
+	ActionKey = "action"
+
+	// ArgvKey the key of the command and arguments
+	ArgvKey = "argv"
+
+	// DirKey the key of the working directory of the command, omitted if empty
+	DirKey = "dir"
+
+	// DryRunKey the key of whether the command is run in DryRun mode
+	DryRunKey = "dry_run"
+
+	// DurationKey the key of the time the command took to run, only on completion
+	DurationKey = "duration"
+
+	// EnvKey the key of the environment variables of the command which differ from the
+	// calling process, omitted if none or unless enabled, see also: Logger.WithEnv
+	EnvKey = "env"
+
+	// ErrorKey the key of the error returned by the command, only on failed completion
+	ErrorKey = "error"
+
+	// ExitCodeKey the key of the exit code of the command, only on completion
+	ExitCodeKey = "exit_code"
+
+	// PidKey the key of the process id of the command, omitted if not yet started
+	PidKey = "pid"
+
+	// PipelineKey the key of the command and arguments of each stage of a Pipeline
+	PipelineKey = "pipeline"
+
+	// ShellKey the key of the command as a POSIX shell command, including environment
+	// variables only if enabled, see also: Logger.WithEnv
+	ShellKey = "shell"
+
+	// StatusKey the key of the classification of how the command exited, only on completion
+	StatusKey = "status"
+
+	// TemplateKey the key of the command and arguments prior to the expansion of variable
+	// references and glob patterns, omitted if not expanded
+	TemplateKey = "template"
+
+	// UnsetKey the key of the environment variables of the calling process removed from the
+	// environment of the command, omitted if none or unless enabled, see also: Logger.WithEnv
+	UnsetKey = "unset"
+)
+
+// Logger implements the log.Logger and log.EventLogger interfaces writing to a *slog.Logger
+type Logger struct {
+	env    bool
+	level  slog.Level
+	logger *slog.Logger
+}
+
+// New returns a new Logger writing to the given *slog.Logger
+// defaults to slog.Default if nil
+func New(l *slog.Logger) *Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+
+	return &Logger{level: slog.LevelInfo, logger: l}
+}
+
+// Level sets the level used when logging, defaults to slog.LevelInfo
+//
+// The completion of a failed command is always logged at slog.LevelError.
+func (l *Logger) Level(level slog.Level) *Logger {
+	l.level = level
+	return l
+}
+
+// WithEnv sets the Logger to log the environment variables of each command which differ
+// from the calling process, see also: EnvKey, UnsetKey
+//
+// Note the values of environment variables, which may be sensitive, are logged.
+func (l *Logger) WithEnv() *Logger {
+	l.env = true
+	return l
+}
+
+// Log implements the log.Logger interface
+func (l *Logger) Log(v ...interface{}) {
+	l.logger.Log(context.Background(), l.level, fmt.Sprint(v...))
+}
+
+// Logf implements the log.Logger interface
+func (l *Logger) Logf(format string, v ...interface{}) {
+	l.logger.Log(context.Background(), l.level, fmt.Sprintf(format, v...))
+}
+
+// LogEvent implements the log.EventLogger interface
+//
+// The record's message is the Event's Message, or the Action if empty.
+func (l *Logger) LogEvent(e log.Event) {
+	level := l.level
+	if e.Complete() && e.Err != nil {
+		level = slog.LevelError
+	}
+
+	msg := e.Message
+	if msg == "" {
+		msg = e.Action
+	}
+
+	l.logger.LogAttrs(context.Background(), level, msg, eventAttrs(e, l.env)...)
+}
+
+// Attrs returns the slog attributes describing the given Event, excluding environment
+// variables, see also: Logger.WithEnv
+func Attrs(e log.Event) []slog.Attr {
+	return eventAttrs(e, false)
+}
+
+// eventAttrs returns the slog attributes describing the given Event, including environment
+// variables if env
+func eventAttrs(e log.Event, env bool) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String(ActionKey, e.Action),
+		slog.Any(ArgvKey, e.Args),
+	}
+
+	if e.Template != nil {
+		attrs = append(attrs, slog.Any(TemplateKey, e.Template))
+	}
+
+	if len(e.Pipeline) > 0 {
+		attrs = append(attrs, slog.Any(PipelineKey, e.Pipeline))
+	}
+
+	shell := e.Shell
+	if env && e.ShellEnv != "" {
+		shell = e.ShellEnv
+	}
+
+	if shell != "" {
+		attrs = append(attrs, slog.String(ShellKey, shell))
+	}
+
+	if e.Dir != "" {
+		attrs = append(attrs, slog.String(DirKey, e.Dir))
+	}
+
+	if env && len(e.Env) > 0 {
+		attrs = append(attrs, slog.Any(EnvKey, e.Env))
+	}
+
+	if env && len(e.Unset) > 0 {
+		attrs = append(attrs, slog.Any(UnsetKey, e.Unset))
+	}
+
+	if e.Pid != 0 {
+		attrs = append(attrs, slog.Int(PidKey, e.Pid))
+	}
+
+	attrs = append(attrs, slog.Bool(DryRunKey, e.DryRun))
+
+	if e.Complete() {
+		attrs = append(attrs,
+			slog.Int(ExitCodeKey, e.ExitCode),
+			slog.Duration(DurationKey, e.Duration),
+			slog.String(StatusKey, e.Status),
+		)
+
+		if e.Err != nil {
+			attrs = append(attrs, slog.String(ErrorKey, e.Err.Error()))
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,120 @@
+//go:build go1.21
+
+package slogadapter_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+	"github.com/scottames/cmder/pkg/log/slogadapter"
+)
+
+func Test_LogEvent(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slogadapter.New(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := cmder.New("bash", "-c", "exit 3").Dir("/tmp").Logger(logger).Run()
+	assert.Error(t, err)
+
+	records := []map[string]interface{}{}
+
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		r := map[string]interface{}{}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, r)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records. Got %d: %v", len(records), records)
+	}
+
+	run, started, complete := records[0], records[1], records[2]
+	msg := fmt.Sprintf("Got %v", records)
+
+	assert.Equal(t, "run", run[slogadapter.ActionKey], msg)
+	assert.Equal(t, []interface{}{"bash", "-c", "exit 3"}, run[slogadapter.ArgvKey], msg)
+	assert.Equal(t, "/tmp", run[slogadapter.DirKey], msg)
+	assert.Equal(t, false, run[slogadapter.DryRunKey], msg)
+	assert.Equal(t, "INFO", run[slog.LevelKey], msg)
+
+	assert.Equal(t, "started", started[slogadapter.ActionKey], msg)
+	assert.Positive(t, started[slogadapter.PidKey], msg)
+	assert.Equal(t, started[slogadapter.PidKey], complete[slogadapter.PidKey], msg)
+
+	assert.Equal(t, "complete", complete[slogadapter.ActionKey], msg)
+	assert.Equal(t, "ERROR", complete[slog.LevelKey], msg)
+	assert.Equal(t, float64(3), complete[slogadapter.ExitCodeKey], msg)
+	assert.Equal(t, "failed", complete[slogadapter.StatusKey], msg)
+	assert.Positive(t, complete[slogadapter.PidKey], msg)
+	assert.Positive(t, complete[slogadapter.DurationKey], msg)
+	assert.Contains(t, complete[slogadapter.ErrorKey], "exit status 3", msg)
+}
+
+func Test_LogEventDryRun(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slogadapter.New(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := cmder.New("echo", "foo").Logger(logger).DryRun().Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	r := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, true, r[slogadapter.DryRunKey], fmt.Sprintf("Got %v", r))
+	assert.NotContains(t, r, slogadapter.PidKey)
+}
+
+func Test_LogEventEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   bool
+		shell string
+	}{
+		{"default", false, "echo foo"},
+		{"with env", true, "SECRET=value echo foo"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		logger := slogadapter.New(slog.New(slog.NewJSONHandler(&buf, nil)))
+		if tt.env {
+			logger.WithEnv()
+		}
+
+		err := cmder.New("echo", "foo").Env("SECRET=value").Logger(logger).DryRun().Run()
+		if err != nil {
+			t.Error(err)
+		}
+
+		r := map[string]interface{}{}
+		if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+
+		msg := fmt.Sprintf("%s: Got %v", tt.name, r)
+		assert.Equal(t, tt.shell, r[slogadapter.ShellKey], msg)
+
+		if tt.env {
+			assert.Equal(t, []interface{}{"SECRET=value"}, r[slogadapter.EnvKey], msg)
+		} else {
+			assert.NotContains(t, r, slogadapter.EnvKey, msg)
+		}
+	}
+}
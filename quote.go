@@ -0,0 +1,56 @@
+package cmder
+
+import "strings"
+
+// shellQuote returns the given string quoted for use as a single word in a POSIX shell
+//
+// strings consisting only of characters which are safe unquoted are returned as is,
+// otherwise the string is single quoted, escaping any single quotes
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+
+	if strings.IndexFunc(s, unsafeRune) < 0 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellJoin returns the given arguments quoted and joined by a single space
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, a := range args {
+		quoted = append(quoted, shellQuote(a))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// shellCmd returns the given command as a POSIX shell command, prefixed with a `cd` to the
+// given dir, if not empty, and the given environment, see also: envDelta.prefix
+func shellCmd(dir string, env envDelta, args []string) string {
+	var b strings.Builder
+
+	if dir != "" {
+		b.WriteString("cd " + shellQuote(dir) + " && ")
+	}
+
+	b.WriteString(env.prefix())
+	b.WriteString(shellJoin(args))
+
+	return b.String()
+}
+
+// unsafeRune returns whether the given rune must be quoted in a POSIX shell
+func unsafeRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	case strings.ContainsRune("_-+=@%:,./", r):
+		return false
+	}
+
+	return true
+}
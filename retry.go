@@ -0,0 +1,236 @@
+package cmder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"time"
+
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// RetryPolicy configures how a command is retried on failure. See also: Cmder.Retry
+type RetryPolicy struct {
+	// MaxAttempts the maximum number of times the command is executed, including the
+	// first attempt. Values less than one are treated as one.
+	MaxAttempts int
+
+	// Backoff returns the delay prior to each retry
+	// defaults to no delay if unset
+	Backoff Backoff
+
+	// Jitter randomizes each delay by up to the given fraction of the delay
+	// e.g. 0.1 will randomize each delay by +/- 10%
+	Jitter float64
+
+	// RetryIf reports whether the given failed attempt should be retried
+	//
+	// If unset every failed attempt is retried, unless the command could not be
+	// found or it's context was canceled or exceeded it's deadline.
+	RetryIf func(Attempt) bool
+}
+
+// Backoff returns the delay prior to the given retry, where the first retry
+// (second attempt) is 1
+type Backoff func(retry int) time.Duration
+
+// Attempt the result of a single execution of a command. See also: Cmder.Attempts
+type Attempt struct {
+	// Number the attempt number, starting at 1
+	Number int
+
+	// Duration the time the attempt took to run
+	Duration time.Duration
+
+	// Err the error returned by the attempt, if any. See also: Error
+	Err error
+
+	// ExitCode the exit code of the attempt
+	ExitCode int
+
+	// Status the classification of how the attempt exited
+	Status Status
+
+	// Stderr the tail of stderr written by the attempt. See also: StderrTailSize
+	Stderr []byte
+}
+
+// ConstantBackoff returns a Backoff which always waits the given delay
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff returns a Backoff starting at the base delay and doubling
+// for each retry, capped at max. A max less than or equal to zero is uncapped.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		delay := base
+
+		for i := 1; i < retry; i++ {
+			delay *= 2
+			if max > 0 && delay >= max {
+				return max
+			}
+		}
+
+		if max > 0 && delay > max {
+			return max
+		}
+
+		return delay
+	}
+}
+
+// RetryOnExitCode returns a RetryIf predicate which retries attempts exiting with
+// any of the given exit codes
+func RetryOnExitCode(codes ...int) func(Attempt) bool {
+	return func(a Attempt) bool {
+		for _, code := range codes {
+			if a.ExitCode == code {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// RetryOnStderr returns a RetryIf predicate which retries attempts where the tail of
+// stderr matches the given regular expression
+func RetryOnStderr(re *regexp.Regexp) func(Attempt) bool {
+	return func(a Attempt) bool {
+		return re.Match(a.Stderr)
+	}
+}
+
+// maxAttempts returns the maximum number of attempts for the RetryPolicy
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// delay returns the delay prior to the given retry
+func (p *RetryPolicy) delay(retry int) time.Duration {
+	if p.Backoff == nil {
+		return 0
+	}
+
+	delay := p.Backoff(retry)
+	if p.Jitter > 0 && delay > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * (2*rand.Float64() - 1)) //nolint:gosec // jitter does not require crypto/rand
+	}
+
+	return delay
+}
+
+// shouldRetry returns whether the given failed attempt should be retried
+func (p *RetryPolicy) shouldRetry(a Attempt) bool {
+	if p.RetryIf != nil {
+		return p.RetryIf(a)
+	}
+
+	switch a.Status { //nolint:exhaustive // all other statuses are retried
+	case StatusNotFound, StatusTimeout, StatusCanceled:
+		return false
+	}
+
+	return true
+}
+
+// retry invokes fn, retrying according to the cmd's RetryPolicy, if any
+// the history of each attempt is recorded on the cmd
+func (c *cmd) retry(fn func() error) error {
+	c.attempts = nil
+
+	policy := c.retryPolicy
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+
+	maxAttempts := policy.maxAttempts()
+
+	for n := 1; ; n++ {
+		c.attempt = n
+
+		if r, ok := c.stdin.(*bytes.Reader); ok && n > 1 {
+			_, _ = r.Seek(0, io.SeekStart)
+		}
+
+		err := fn()
+		if c.isDryRun() {
+			return err
+		}
+
+		a := Attempt{
+			Number:   n,
+			Duration: c.Duration(),
+			Err:      err,
+			ExitCode: c.exitCode,
+			Status:   c.status,
+		}
+
+		if c.stderrTail != nil {
+			a.Stderr = c.stderrTail.Bytes()
+		}
+
+		c.attempts = append(c.attempts, a)
+
+		if err == nil || n >= maxAttempts || !policy.shouldRetry(a) {
+			return err
+		}
+
+		delay := policy.delay(n)
+		c.logRetry(n, maxAttempts, delay, err)
+
+		if c.sleep(delay) != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for the given duration or until the cmd's context is done
+func (c *cmd) sleep(d time.Duration) error {
+	if d <= 0 {
+		return c.ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// logRetry logs the failed attempt n prior to retrying the cmd
+func (c *cmd) logRetry(n, maxAttempts int, delay time.Duration, err error) {
+	c.logAction(log.LoggerRetryKey, "%v attempt %d/%d failed (%v), retrying in %s", c.strings, n, maxAttempts, errCause(err), delay)
+}
+
+// attemptStr returns the attempt counter for the cmd if a RetryPolicy has been set
+func (c *cmd) attemptStr() string {
+	if c.retryPolicy == nil || c.retryPolicy.maxAttempts() < 2 { //nolint:gomnd // a single attempt is not retried
+		return ""
+	}
+
+	return fmt.Sprintf(" attempt %d/%d", c.attempt, c.retryPolicy.maxAttempts())
+}
+
+// errCause returns the underlying cause of an *Error or the error itself
+func errCause(err error) error {
+	if e, ok := err.(*Error); ok { //nolint:errorlint // only unwrapping *Error returned from endState
+		return e.Err
+	}
+
+	return err
+}
@@ -0,0 +1,148 @@
+package cmder_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+// failUntil returns a bash script which fails until it has been run n times
+func failUntil(t *testing.T, n int) string {
+	t.Helper()
+
+	counter := filepath.Join(t.TempDir(), "counter")
+
+	return fmt.Sprintf(
+		"n=$(cat %[1]s 2>/dev/null || echo 0); n=$((n+1)); echo $n > %[1]s; [ $n -ge %[2]d ]",
+		counter, n,
+	)
+}
+
+func Test_RetrySucceeds(t *testing.T) {
+	cmd := cmder.New("bash", "-c", failUntil(t, 3)).
+		Logger(testLogger{}).
+		Retry(cmder.RetryPolicy{MaxAttempts: 5, Backoff: cmder.ConstantBackoff(time.Millisecond)})
+
+	err := cmd.Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	attempts := cmd.Attempts()
+	assert.Len(t, attempts, 3)
+	assert.Equal(t, 1, attempts[0].ExitCode)
+	assert.Equal(t, 3, attempts[2].Number)
+	assert.Equal(t, cmder.StatusSuccess, attempts[2].Status)
+	assert.Equal(t, 0, cmd.ExitCode())
+	assert.Equal(t, attempts[2].Duration, cmd.Duration())
+}
+
+func Test_RetryExhausted(t *testing.T) {
+	cmd := cmder.New("bash", "-c", "exit 2").
+		Silent().
+		Retry(cmder.RetryPolicy{MaxAttempts: 3})
+
+	err := cmd.Run()
+
+	var cmdErr *cmder.Error
+	assert.True(t, errors.As(err, &cmdErr), "Expected *cmder.Error. Got %T.", err)
+	assert.Len(t, cmd.Attempts(), 3)
+	assert.Equal(t, 2, cmd.ExitCode())
+}
+
+func Test_RetryIf(t *testing.T) {
+	tests := []struct {
+		name     string
+		retryIf  func(cmder.Attempt) bool
+		expected int
+	}{
+		{"exit code match", cmder.RetryOnExitCode(2), 3},
+		{"exit code mismatch", cmder.RetryOnExitCode(3), 1},
+		{"stderr match", cmder.RetryOnStderr(regexp.MustCompile("try again")), 3},
+		{"stderr mismatch", cmder.RetryOnStderr(regexp.MustCompile("fatal")), 1},
+	}
+
+	for _, tt := range tests {
+		cmd := cmder.New("bash", "-c", "echo try again >&2; exit 2").
+			Silent().
+			Out(&bytes.Buffer{}, &bytes.Buffer{}).
+			Retry(cmder.RetryPolicy{MaxAttempts: 3, RetryIf: tt.retryIf})
+
+		_ = cmd.Run()
+
+		msg := fmt.Sprintf("%s: Expected %d attempts. Got %d.", tt.name, tt.expected, len(cmd.Attempts()))
+		assert.Len(t, cmd.Attempts(), tt.expected, msg)
+	}
+}
+
+func Test_RetryCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	cmd := cmder.New("bash", "-c", "exit 1").
+		Ctx(ctx).
+		Silent().
+		Retry(cmder.RetryPolicy{MaxAttempts: 3, Backoff: cmder.ConstantBackoff(time.Hour)})
+
+	start := time.Now()
+	err := cmd.Run()
+
+	assert.Error(t, err)
+	assert.Len(t, cmd.Attempts(), 1)
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func Test_RetryInput(t *testing.T) {
+	expected := foo + foo
+
+	var buf bytes.Buffer
+
+	cmd := cmder.New("bash", "-c", "cat; exit 1").
+		In([]byte(foo)...).
+		Out(&buf).
+		Silent().
+		Retry(cmder.RetryPolicy{MaxAttempts: 2})
+
+	_ = cmd.Run()
+
+	actual := buf.String()
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_RetryCombinedOutput(t *testing.T) {
+	expected := foo + newLineStr
+
+	out, err := cmder.New("bash", "-c", "echo foo; "+failUntil(t, 2)).
+		Retry(cmder.RetryPolicy{MaxAttempts: 2}).
+		CombinedOutput()
+	if err != nil {
+		t.Error(err)
+	}
+
+	actual := string(out)
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
+
+func Test_ExponentialBackoff(t *testing.T) {
+	expected := []time.Duration{10, 20, 40, 50, 50}
+	backoff := cmder.ExponentialBackoff(10, 50)
+
+	actual := []time.Duration{}
+	for i := 1; i <= len(expected); i++ {
+		actual = append(actual, backoff(i))
+	}
+
+	msg := fmt.Sprintf("Expected %v. Got %v.", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
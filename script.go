@@ -0,0 +1,206 @@
+package cmder
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	recorder   *Script
+	recorderMu sync.Mutex
+)
+
+// Script records the commands run through cmder as a reproducible POSIX shell script,
+// see also: NewScript, Record
+//
+// Each command is recorded with it's working directory, as a `cd`, the environment
+// variables added via Env, as `export` lines, or via `env` if variables are removed via
+// Unsetenv, ClearEnv or InheritEnv, and it's stdin when provided via In or an *os.File.
+// Commands run in DryRun mode are recorded as if executed, such that the Script shows
+// the commands which would be run.
+type Script struct {
+	dir      string
+	mu       sync.Mutex
+	commands []scriptCommand
+}
+
+// scriptCommand a single command or pipeline recorded by a Script
+type scriptCommand struct {
+	dir    string
+	env    []string
+	stages []string
+}
+
+// NewScript returns a new Script, recording commands relative to the current working directory
+func NewScript() *Script {
+	dir, _ := os.Getwd()
+
+	return &Script{dir: dir}
+}
+
+// Record sets the Script recording every command run through cmder
+// passing nil stops recording
+func Record(s *Script) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+
+	recorder = s
+}
+
+// String returns the recorded commands as a POSIX shell script
+func (s *Script) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("#!/bin/sh\nset -e\n")
+
+	if s.dir != "" {
+		b.WriteString("\ncd " + shellQuote(s.dir) + "\n")
+	}
+
+	dir := s.dir
+	exported := map[string]string{}
+
+	for _, c := range s.commands {
+		b.WriteString("\n")
+
+		if c.dir != dir {
+			b.WriteString("cd " + shellQuote(c.dir) + "\n")
+			dir = c.dir
+		}
+
+		writeExports(&b, exported, c.env)
+
+		b.WriteString(strings.Join(c.stages, " | ") + "\n")
+	}
+
+	return b.String()
+}
+
+// WriteTo writes the recorded commands as a POSIX shell script to the given writer
+// implementing the io.WriterTo interface
+func (s *Script) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, s.String())
+	return int64(n), err
+}
+
+// record records the given commands, run as a pipeline if more than one
+//
+// subsequent stages of a pipeline are recorded with their environment as a prefix and
+// run in a subshell if their working directory differs from the first stage
+func (s *Script) record(cmds ...*cmd) {
+	sc := scriptCommand{dir: s.dir}
+
+	for i, c := range cmds {
+		r := c.state()
+		stage := shellJoin(r.args)
+
+		if i > 0 {
+			stage = pipelineStage(r.delta.prefix()+stage, s.resolve(r.dir), sc.dir)
+			sc.stages = append(sc.stages, stage)
+
+			continue
+		}
+
+		sc.dir = s.resolve(r.dir)
+
+		// environments which are cleared or unset variables are run with env
+		// rather than exported, such that subsequent commands are unaffected
+		if r.delta.clear || len(r.delta.unset) > 0 {
+			stage = r.delta.prefix() + stage
+		} else {
+			sc.env = r.delta.set
+		}
+
+		sc.stages = append(sc.stages, stdinSource(c.stdin, stage))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, sc)
+}
+
+// resolve returns the given working directory of a command relative to the Script
+func (s *Script) resolve(dir string) string {
+	if dir == "" {
+		return s.dir
+	}
+
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+
+	return filepath.Join(s.dir, dir)
+}
+
+// recordCmds records the given commands with the package Script, if any
+func recordCmds(cmds ...*cmd) {
+	recorderMu.Lock()
+	s := recorder
+	recorderMu.Unlock()
+
+	if s != nil {
+		s.record(cmds...)
+	}
+}
+
+// writeExports writes the export and unset lines required to change the exported
+// environment variables to the given environment
+func writeExports(b *strings.Builder, exported map[string]string, env []string) {
+	want := envMap(env)
+
+	unset := []string{}
+
+	for k := range exported {
+		if _, ok := want[k]; !ok {
+			unset = append(unset, k)
+		}
+	}
+
+	sort.Strings(unset)
+
+	for _, k := range unset {
+		b.WriteString("unset " + k + "\n")
+		delete(exported, k)
+	}
+
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+
+		// superseded by a later duplicate key or already exported
+		if cur, ok := exported[k]; want[k] != v || (ok && cur == v) {
+			continue
+		}
+
+		b.WriteString("export " + k + "=" + shellQuote(v) + "\n")
+		exported[k] = v
+	}
+}
+
+// stdinSource returns the given command with it's stdin source, if any
+// input provided via In is written with printf, files are redirected
+func stdinSource(stdin io.Reader, command string) string {
+	switch r := stdin.(type) {
+	case *bytes.Reader:
+		input := make([]byte, r.Size())
+		n, _ := r.ReadAt(input, 0)
+
+		return "printf '%s' " + shellQuote(string(input[:n])) + " | " + command
+	case *os.File:
+		if r == os.Stdin || r == nil {
+			return command
+		}
+
+		return command + " < " + shellQuote(r.Name())
+	}
+
+	return command
+}
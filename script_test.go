@@ -0,0 +1,82 @@
+package cmder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_Script(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := cmder.NewScript()
+	cmder.Record(script)
+	t.Cleanup(func() { cmder.Record(nil) })
+
+	_ = cmder.New(echo, "it's", "$HOME").Dir(tmp).Env("FOO=bar baz").DryRun().Run()
+	_ = cmder.New("cat").In([]byte("a'b\n")...).Dir(tmp).Env("FOO=bar baz", "BAR=1").DryRun().Run()
+	_ = cmder.Pipe(cmder.New("ls", "-la").Dir(tmp), cmder.New("wc", "-l")).DryRun().Run()
+	_ = cmder.Pipe(cmder.New("ls").Dir(tmp), cmder.New("grep", "x").Dir(tmp).Env("GREP_COLOR=1")).DryRun().Run()
+	_ = cmder.New("go", "version").Dir("sub").DryRun().Run()
+
+	expected := `#!/bin/sh
+set -e
+
+cd ` + wd + `
+
+cd /tmp
+export FOO='bar baz'
+echo 'it'\''s' '$HOME'
+
+export BAR=1
+printf '%s' 'a'\''b
+' | cat
+
+unset BAR
+unset FOO
+ls -la | (cd ` + wd + ` && wc -l)
+
+ls | GREP_COLOR=1 grep x
+
+cd ` + filepath.Join(wd, "sub") + `
+go version
+`
+
+	if diff := deep.Equal(script.String(), expected); diff != nil {
+		t.Errorf("%s\n%s", diff, script.String())
+	}
+}
+
+func Test_ScriptEnv(t *testing.T) {
+	t.Setenv("CMDER_TEST", "orig")
+
+	script := cmder.NewScript()
+	cmder.Record(script)
+	t.Cleanup(func() { cmder.Record(nil) })
+
+	_ = cmder.New(echo, foo).Env("FOO=bar").DryRun().Run()
+	_ = cmder.New(echo, foo).Env("FOO=bar").Unsetenv("CMDER_TEST").DryRun().Run()
+	_ = cmder.New(echo, foo).ClearEnv().Env("FOO=bar").DryRun().Run()
+
+	expected := `
+export FOO=bar
+echo foo
+
+unset FOO
+env -u CMDER_TEST FOO=bar echo foo
+
+env -i FOO=bar echo foo
+`
+
+	if actual := script.String(); !strings.HasSuffix(actual, expected) {
+		t.Errorf("Expected suffix:\n%s\nGot:\n%s", expected, actual)
+	}
+}
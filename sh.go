@@ -0,0 +1,75 @@
+package cmder
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shell a shell used to run scripts, see also: Sh
+type Shell struct {
+	// Args the shell executable and arguments preceding the script, e.g. bash -c
+	Args []string
+
+	// Preamble the commands prepended to each script, e.g. set -euo pipefail
+	Preamble string
+}
+
+// DefaultShell the Shell used by Sh
+var DefaultShell = Shell{
+	Args:     []string{"bash", "-c"},
+	Preamble: "set -euo pipefail",
+}
+
+// shellArg an argument interpolated into a script, quoted for a POSIX shell when formatted
+type shellArg struct {
+	v interface{}
+}
+
+// Sh returns a new Cmder running the script formatted from the given format and arguments
+// with the DefaultShell, see also: Shell.Sh
+//
+//	cmder.Sh("git commit -m %s && git push %s", message, remote)
+func Sh(format string, args ...interface{}) Cmder {
+	return DefaultShell.Sh(format, args...)
+}
+
+// Sh returns a new Cmder running the script formatted from the given format and arguments
+// with the Shell, the Preamble is prepended to the script
+//
+// The script is formatted in the manner of fmt.Sprintf, except each argument is formatted
+// and then quoted as a single word for a POSIX shell, such that arguments can never be
+// interpreted as shell syntax. Arguments should therefore not be quoted in the format.
+//
+// The script, rather than the arguments of the Shell, is logged when the command is run.
+func (s Shell) Sh(format string, args ...interface{}) Cmder {
+	quoted := make([]interface{}, 0, len(args))
+	for _, a := range args {
+		quoted = append(quoted, shellArg{v: a})
+	}
+
+	script := fmt.Sprintf(format, quoted...)
+
+	body := script
+	if s.Preamble != "" {
+		body = s.Preamble + "\n" + script
+	}
+
+	c := New(append(append([]string(nil), s.Args...), body)...).(*cmd)
+	c.shell = append([]string(nil), s.Args...)
+	c.script = script
+
+	return c
+}
+
+// Format implements the fmt.Formatter interface, formatting the argument with the given
+// verb and flags and quoting the result for a POSIX shell
+func (a shellArg) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, shellQuote(fmt.Sprintf(fmt.FormatString(f, verb), a.v)))
+}
+
+// scriptMsg returns the message logged for a cmd running a script via Sh
+// subsequent lines of the script are indented
+func (c *cmd) scriptMsg() string {
+	return fmt.Sprintf("%v %s", c.shell, strings.ReplaceAll(c.script, "\n", "\n    "))
+}
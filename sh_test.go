@@ -0,0 +1,40 @@
+package cmder_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_Sh(t *testing.T) {
+	expected := "it's $HOME; `whoami` \"a b\" 42\n"
+
+	actual, err := cmder.Sh("printf '%%s\\n' %s%d", "it's $HOME; `whoami` \"a b\" ", 42).Output()
+	if err != nil {
+		t.Error(err)
+	}
+
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, string(actual), msg)
+}
+
+func Test_ShPipefail(t *testing.T) {
+	err := cmder.Sh("false | true").Silent().Run()
+	assert.Error(t, err, "Expected pipefail to be set by default")
+
+	err = cmder.Shell{Args: []string{"sh", "-c"}}.Sh("false | true").Silent().Run()
+	assert.NoError(t, err)
+}
+
+func Test_ShLogCmd(t *testing.T) {
+	expected := "[bash -c] cd 'my dir'\n    ls -la"
+
+	cmder.Sh("cd %s\nls -la", "my dir").Logger(testLogger{}).LogCmd()
+
+	actual := logCmdStr
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
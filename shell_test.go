@@ -0,0 +1,42 @@
+package cmder_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_ShellString(t *testing.T) {
+	tests := []struct {
+		cmd      cmder.Cmder
+		expected string
+	}{
+		{cmder.New(echo, foo), "echo foo"},
+		{cmder.New(echo, "hello world", "it's", ""), `echo 'hello world' 'it'\''s' ''`},
+		{cmder.New(echo, "$HOME", "a;b", "--flag=x/y:z"), `echo '$HOME' 'a;b' --flag=x/y:z`},
+		{cmder.New(echo, foo).Dir("/tmp/my dir"), "cd '/tmp/my dir' && echo foo"},
+		{cmder.New(echo, foo).Env("FOO=bar baz", "BAR=1").Dir(tmp), "cd /tmp && FOO='bar baz' BAR=1 echo foo"},
+	}
+
+	for _, tt := range tests {
+		actual := tt.cmd.ShellString()
+		msg := fmt.Sprintf("Expected '%s' Got '%s'", tt.expected, actual)
+		assert.Equal(t, tt.expected, actual, msg)
+	}
+}
+
+func Test_PipeShellString(t *testing.T) {
+	expected := "cd /tmp && FOO=1 ls -la | (cd / && grep 'a b') | wc -l"
+
+	actual := cmder.Pipe(
+		cmder.New("ls", "-la").Dir(tmp).Env("FOO=1"),
+		cmder.New("grep", "a b").Dir("/"),
+		cmder.New("wc", "-l").Dir(tmp),
+	).ShellString()
+
+	msg := fmt.Sprintf("Expected '%s' Got '%s'", expected, actual)
+	assert.Equal(t, expected, actual, msg)
+}
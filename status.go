@@ -0,0 +1,170 @@
+package cmder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os/exec"
+	"syscall"
+)
+
+// Status the classification of how a command exited
+type Status int
+
+const (
+	// StatusPending the command has yet to complete
+	StatusPending Status = iota
+
+	// StatusSuccess the command exited with a zero exit code
+	StatusSuccess
+
+	// StatusFailed the command exited with a non-zero exit code
+	StatusFailed
+
+	// StatusSignaled the command was terminated by a signal other than SIGKILL
+	StatusSignaled
+
+	// StatusKilled the command was killed, either via Kill or by SIGKILL
+	StatusKilled
+
+	// StatusNotFound the command's executable could not be found
+	StatusNotFound
+
+	// StatusTimeout the command was terminated as the deadline of it's context was exceeded
+	StatusTimeout
+
+	// StatusCanceled the command was terminated as it's context was canceled
+	StatusCanceled
+
+	// StatusError the command failed for any other reason, e.g. failing to start or I/O errors
+	StatusError
+)
+
+var (
+	// ErrNotFound the command's executable could not be found. See StatusNotFound.
+	ErrNotFound = errors.New("command not found")
+
+	// ErrTimeout the command's context deadline was exceeded. See StatusTimeout.
+	ErrTimeout = errors.New("command timed out")
+
+	// ErrCanceled the command's context was canceled. See StatusCanceled.
+	ErrCanceled = errors.New("command canceled")
+
+	// ErrKilled the command was killed. See StatusKilled.
+	ErrKilled = errors.New("command killed")
+)
+
+// String implements the fmt.Stringer interface
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusSuccess:
+		return "success"
+	case StatusFailed:
+		return "failed"
+	case StatusSignaled:
+		return "signaled"
+	case StatusKilled:
+		return "killed"
+	case StatusNotFound:
+		return "not found"
+	case StatusTimeout:
+		return "timeout"
+	case StatusCanceled:
+		return "canceled"
+	case StatusError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// err returns the sentinel error for the given Status, if any
+func (s Status) err() error {
+	switch s { //nolint:exhaustive // only statuses with sentinel errors
+	case StatusNotFound:
+		return ErrNotFound
+	case StatusTimeout:
+		return ErrTimeout
+	case StatusCanceled:
+		return ErrCanceled
+	case StatusKilled:
+		return ErrKilled
+	}
+
+	return nil
+}
+
+// exitStatus an interface implementing the ExitStatus method
+type exitStatus interface {
+	ExitStatus() int
+}
+
+// exitStatus sets the exit code, signal and Status for the given cmd's error
+// if nil found, exit code will always be 0
+func (c *cmd) exitStatus(err error) {
+	c.signal = nil
+
+	if err == nil {
+		c.exitCode = 0
+		c.status = StatusSuccess
+
+		return
+	}
+
+	c.exitCode = -1
+	c.signal = exitSignal(err)
+
+	var exitErr *exec.ExitError
+
+	switch e := err.(type) { //nolint:errorlint // errors implementing ExitStatus directly
+	case exitStatus:
+		c.exitCode = e.ExitStatus()
+	default:
+		if errors.As(err, &exitErr) {
+			c.exitCode = exitErr.ExitCode()
+		}
+	}
+
+	c.status = c.classify(err, exitErr != nil)
+}
+
+// classify returns the Status for the given non-nil error
+// exited represents whether the process ran and exited
+func (c *cmd) classify(err error, exited bool) Status {
+	if ctxErr := c.ctx.Err(); ctxErr != nil && (c.signal != nil || !exited || errors.Is(err, ctxErr)) {
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			return StatusTimeout
+		}
+
+		return StatusCanceled
+	}
+
+	switch {
+	case c.signal == syscall.SIGKILL || (c.isKilled() && c.signal != nil):
+		return StatusKilled
+	case c.signal != nil:
+		return StatusSignaled
+	case !exited && isNotFound(err):
+		return StatusNotFound
+	case c.exitCode > 0:
+		return StatusFailed
+	}
+
+	return StatusError
+}
+
+// isNotFound returns whether the given error represents an executable which could not be found
+func isNotFound(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return true
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && pathErr.Op == "chdir" {
+		return false
+	}
+
+	return errors.Is(err, fs.ErrNotExist)
+}
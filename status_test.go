@@ -0,0 +1,116 @@
+package cmder_test
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+func Test_StatusSuccess(t *testing.T) {
+	cmd := cmder.New(echo, foo).Silent()
+
+	assert.Equal(t, cmder.StatusPending, cmd.Status())
+
+	err := cmd.Run()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, cmder.StatusSuccess, cmd.Status())
+	assert.Equal(t, 0, cmd.ExitCode())
+	assert.Nil(t, cmd.Signal())
+}
+
+func Test_StatusFailed(t *testing.T) {
+	cmd := cmder.New("bash", "-c", "exit 3").Silent()
+
+	err := cmd.Run()
+
+	assert.Error(t, err)
+	assert.Equal(t, cmder.StatusFailed, cmd.Status())
+	assert.Equal(t, 3, cmd.ExitCode())
+	assert.Nil(t, cmd.Signal())
+}
+
+func Test_StatusSignaled(t *testing.T) {
+	cmd := cmder.New("bash", "-c", "kill -TERM $$").Silent()
+
+	err := cmd.Run()
+
+	assert.Error(t, err)
+	assert.Equal(t, cmder.StatusSignaled, cmd.Status())
+	assert.Equal(t, -1, cmd.ExitCode())
+	assert.Equal(t, syscall.SIGTERM, cmd.Signal())
+}
+
+func Test_StatusKilled(t *testing.T) {
+	cmd := cmder.New(sleep, five).Silent()
+
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Kill()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = cmd.Wait()
+
+	assert.True(t, errors.Is(err, cmder.ErrKilled), "Expected ErrKilled. Got %v.", err)
+	assert.Equal(t, cmder.StatusKilled, cmd.Status())
+	assert.Equal(t, syscall.SIGKILL, cmd.Signal())
+}
+
+func Test_StatusNotFound(t *testing.T) {
+	for _, name := range []string{"cmder-does-not-exist", "/cmder/does/not/exist"} {
+		cmd := cmder.New(name).Silent()
+
+		err := cmd.Run()
+
+		assert.True(t, errors.Is(err, cmder.ErrNotFound), "Expected ErrNotFound. Got %v.", err)
+		assert.Equal(t, cmder.StatusNotFound, cmd.Status())
+		assert.Equal(t, -1, cmd.ExitCode())
+	}
+}
+
+func Test_StatusDirNotFound(t *testing.T) {
+	cmd := cmder.New(echo, foo).Dir("/cmder/does/not/exist").Silent()
+
+	err := cmd.Run()
+
+	assert.False(t, errors.Is(err, cmder.ErrNotFound), "Expected missing dir not to be ErrNotFound")
+	assert.Equal(t, cmder.StatusError, cmd.Status())
+}
+
+func Test_StatusTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	cmd := cmder.New(sleep, five).Ctx(ctx).Silent()
+
+	err := cmd.Run()
+
+	assert.True(t, errors.Is(err, cmder.ErrTimeout), "Expected ErrTimeout. Got %v.", err)
+	assert.Equal(t, cmder.StatusTimeout, cmd.Status())
+}
+
+func Test_StatusCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	cmd := cmder.New(sleep, five).Ctx(ctx).Silent()
+
+	err := cmd.Run()
+
+	assert.True(t, errors.Is(err, cmder.ErrCanceled), "Expected ErrCanceled. Got %v.", err)
+	assert.False(t, errors.Is(err, cmder.ErrTimeout), "Expected not to be ErrTimeout")
+	assert.Equal(t, cmder.StatusCanceled, cmd.Status())
+}
@@ -0,0 +1,211 @@
+package cmder_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+// grandchild a bash script starting a background process, printing it's pid and waiting
+const grandchild = "sleep 30 & echo $!; wait"
+
+// processGone returns whether the process with the given pid has exited
+// waiting up to the given timeout, a zombie is considered to have exited
+func processGone(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return true
+		}
+
+		// the state follows the executable name in parenthesis
+		fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+		if len(fields) > 0 && fields[0] == "Z" {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+// readPid reads a single pid from the given reader
+func readPid(t *testing.T, r *os.File) int {
+	t.Helper()
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pid
+}
+
+func Test_ProcessGroupKill(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	cmd := cmder.New("bash", "-c", grandchild).ProcessGroup().Silent()
+
+	err = cmd.Start(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := readPid(t, r)
+
+	err = cmd.Kill()
+	if err != nil {
+		t.Error(err)
+	}
+
+	_ = cmd.Wait()
+
+	assert.True(t, processGone(pid, 5*time.Second), "Expected grandchild %d to be killed", pid)
+}
+
+func Test_ProcessGroupCtx(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error)
+
+	go func() {
+		errCh <- cmder.New("bash", "-c", grandchild).Ctx(ctx).ProcessGroup().Silent().Run(w)
+	}()
+
+	pid := readPid(t, r)
+
+	select {
+	case <-errCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected Run to return once the context deadline was exceeded")
+	}
+
+	assert.True(t, processGone(pid, 5*time.Second), "Expected grandchild %d to be killed", pid)
+}
+
+func Test_ProcessGroupTerminate(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	cmd := cmder.New("bash", "-c", grandchild).ProcessGroup().Silent()
+
+	err = cmd.Start(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := readPid(t, r)
+
+	errCh := make(chan error)
+	go func() { errCh <- cmd.Wait() }()
+
+	err = cmd.Terminate(5 * time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	<-errCh
+
+	assert.True(t, processGone(pid, 5*time.Second), "Expected grandchild %d to be terminated", pid)
+}
+
+// ignoreTerm a bash script starting a background process ignoring SIGTERM, printing it's pid
+// and waiting
+const ignoreTerm = `(trap "" TERM; sleep 30) & echo $!; wait`
+
+func Test_ProcessGroupTerminateKill(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	cmd := cmder.New("bash", "-c", ignoreTerm).ProcessGroup().Silent()
+
+	err = cmd.Start(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := readPid(t, r)
+
+	errCh := make(chan error)
+	go func() { errCh <- cmd.Wait() }()
+
+	err = cmd.Terminate(200 * time.Millisecond)
+	if err != nil {
+		t.Error(err)
+	}
+
+	<-errCh
+
+	assert.True(t, processGone(pid, 5*time.Second), "Expected grandchild %d to be killed", pid)
+}
+
+func Test_ProcessGroupCtxKill(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error)
+
+	go func() {
+		errCh <- cmder.New("bash", "-c", ignoreTerm).
+			Ctx(ctx).
+			GracePeriod(200 * time.Millisecond).
+			ProcessGroup().
+			Silent().
+			Run(w)
+	}()
+
+	pid := readPid(t, r)
+
+	select {
+	case <-errCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected Run to return once the context deadline was exceeded")
+	}
+
+	assert.True(t, processGone(pid, 5*time.Second), "Expected grandchild %d to be killed", pid)
+}
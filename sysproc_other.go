@@ -0,0 +1,22 @@
+//go:build !unix
+
+package cmder
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setSysProcAttr is a no-op as process groups are not supported on this platform
+func (c *cmd) setSysProcAttr() {}
+
+// isGroupLeader always returns false as process groups are not supported on this platform
+func isGroupLeader(c *exec.Cmd) bool {
+	return false
+}
+
+// signalGroup sends the given signal to the given process only
+// as process groups are not supported on this platform
+func signalGroup(p *os.Process, sig os.Signal) error {
+	return p.Signal(sig)
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package cmder
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setSysProcAttr configures the process group and parent death signal of the exec.Cmd
+func (c *cmd) setSysProcAttr() {
+	if !c.processGroup && c.pdeathsig == 0 {
+		return
+	}
+
+	if c.cmd.SysProcAttr == nil {
+		c.cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	c.cmd.SysProcAttr.Setpgid = c.processGroup
+	setPdeathsig(c.cmd.SysProcAttr, c.pdeathsig)
+}
+
+// isGroupLeader returns whether the given exec.Cmd was started in it's own process group
+func isGroupLeader(c *exec.Cmd) bool {
+	return c.SysProcAttr != nil && c.SysProcAttr.Setpgid
+}
+
+// signalGroup sends the given signal to the process group led by the given process
+func signalGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+
+	err := syscall.Kill(-p.Pid, s)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+
+	return err
+}
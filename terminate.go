@@ -0,0 +1,173 @@
+package cmder
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/scottames/cmder/pkg/log"
+)
+
+// DefaultCancelSignal the signal sent to a command when terminating gracefully
+// if no CancelSignal has been set
+var DefaultCancelSignal os.Signal = syscall.SIGTERM
+
+// DefaultGracePeriod the time a command is given to exit after being sent it's CancelSignal
+// when it's context is done, prior to being killed, if no GracePeriod has been set
+var DefaultGracePeriod = 10 * time.Second //nolint:gomnd // as per docker stop
+
+// setCancel configures the exec.Cmd to terminate gracefully when the cmd's context is done
+// if either a CancelSignal or GracePeriod has been set, otherwise exec's default of
+// killing the process is used
+//
+// if the cmd was started in it's own process group the entire group is signaled, and killed
+// once the grace period has elapsed, see also: killAfter
+func (c *cmd) setCancel() {
+	// the exec.Cmd is signaled directly as Cancel may be invoked prior to Start returning
+	command := c.cmd
+
+	if c.cancelSignal == nil && c.gracePeriod <= 0 {
+		if c.processGroup {
+			c.cmd.Cancel = func() error {
+				return c.getExecutor().Signal(command, os.Kill)
+			}
+		}
+
+		return
+	}
+
+	sig := c.terminateSignal()
+	grace := c.terminateGracePeriod()
+
+	c.cmd.Cancel = func() error {
+		c.logAction(log.LoggerKillKey, "%v terminating (%v), grace period %s", c.strings, sig, grace)
+
+		// exec's WaitDelay only kills the process itself
+		if isGroupLeader(command) {
+			_, done := c.running()
+			go func() { _ = c.killAfter(command, grace, done) }()
+		}
+
+		return c.getExecutor().Signal(command, sig)
+	}
+	c.cmd.WaitDelay = grace
+}
+
+// killAfter kills the given exec.Cmd once the given grace period has elapsed, unless done
+// is closed first
+//
+// If the exec.Cmd was started in it's own process group the group is killed once the grace
+// period has elapsed if any of it's processes remain, regardless of done, as processes
+// started by the cmd may ignore the signal and outlive it.
+func (c *cmd) killAfter(command *exec.Cmd, grace time.Duration, done <-chan struct{}) error {
+	t := time.NewTimer(grace)
+	defer t.Stop()
+
+	select {
+	case <-done:
+		// signal 0 checks whether any process of the group remains
+		if !isGroupLeader(command) || c.getExecutor().Signal(command, syscall.Signal(0)) != nil {
+			return nil
+		}
+
+		<-t.C
+	case <-t.C:
+		c.setKilled()
+	}
+
+	c.logAction(log.LoggerKillKey, "%v killing, grace period %s exceeded", c.strings, grace)
+
+	return ignoreProcessDone(c.getExecutor().Signal(command, os.Kill))
+}
+
+// terminateSignal returns the signal used to gracefully terminate the cmd
+func (c *cmd) terminateSignal() os.Signal {
+	if c.cancelSignal != nil {
+		return c.cancelSignal
+	}
+
+	return DefaultCancelSignal
+}
+
+// terminateGracePeriod returns the time the cmd is given to exit once terminated when it's
+// context is done, defaulting to DefaultGracePeriod
+func (c *cmd) terminateGracePeriod() time.Duration {
+	if c.gracePeriod > 0 {
+		return c.gracePeriod
+	}
+
+	return DefaultGracePeriod
+}
+
+// signalProcess sends the given signal to the cmd's process via the cmd's Executor
+// the default Executor signals the process group if ProcessGroup has been set
+func (c *cmd) signalProcess(sig os.Signal) error {
+	command, _ := c.running()
+	if command == nil {
+		return errors.New("process expected to be started. found nil process")
+	}
+
+	return c.getExecutor().Signal(command, sig)
+}
+
+// setProcess records the started process of the cmd, such that it may be signaled from
+// other goroutines, e.g. via Terminate while Run is in progress
+func (c *cmd) setProcess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.proc = c.cmd
+	c.process = c.cmd.Process
+}
+
+// running returns the exec.Cmd of the cmd's started process, or nil if not yet started, and
+// the channel closed once it has completed
+func (c *cmd) running() (*exec.Cmd, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.proc, c.done
+}
+
+// setKilled marks the cmd as killed, see also: StatusKilled
+func (c *cmd) setKilled() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.killed = true
+}
+
+// isKilled returns whether the cmd has been killed, see also: setKilled
+func (c *cmd) isKilled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.killed
+}
+
+// ignoreProcessDone returns nil if the given error is os.ErrProcessDone
+func ignoreProcessDone(err error) error {
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+
+	return err
+}
+
+// markDone signals that the cmd has completed to any pending Terminate
+func (c *cmd) markDone() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done == nil {
+		return
+	}
+
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
@@ -0,0 +1,158 @@
+package cmder_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scottames/cmder"
+)
+
+const trapTerm = `trap "echo trapped; exit 0" %s; while :; do sleep 0.01; done`
+
+func Test_Terminate(t *testing.T) {
+	cmd := cmder.New("bash", "-c", fmt.Sprintf(trapTerm, "TERM")).Silent()
+
+	var buf bytes.Buffer
+
+	err := cmd.Start(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// allow the trap to be set
+	time.Sleep(100 * time.Millisecond)
+
+	errCh := make(chan error)
+	go func() { errCh <- cmd.Wait() }()
+
+	start := time.Now()
+
+	err = cmd.Terminate(5 * time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, "trapped\n", buf.String())
+}
+
+func Test_TerminateGraceExceeded(t *testing.T) {
+	cmd := cmder.New("bash", "-c", `trap "" TERM; while :; do :; done`).Silent()
+
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	errCh := make(chan error)
+	go func() { errCh <- cmd.Wait() }()
+
+	err = cmd.Terminate(100 * time.Millisecond)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = <-errCh
+	assert.True(t, errors.Is(err, cmder.ErrKilled), "Expected ErrKilled. Got %v.", err)
+}
+
+func Test_GracePeriodCtx(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal syscall.Signal
+		trap   string
+	}{
+		{"default signal", 0, "TERM"},
+		{"cancel signal", syscall.SIGINT, "INT"},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		var buf bytes.Buffer
+
+		cmd := cmder.New("bash", "-c", fmt.Sprintf(trapTerm, tt.trap)).
+			Ctx(ctx).
+			GracePeriod(5 * time.Second).
+			Silent().
+			Out(&buf)
+
+		if tt.signal != 0 {
+			cmd.CancelSignal(tt.signal)
+		}
+
+		err := cmd.Run()
+
+		assert.True(t, errors.Is(err, cmder.ErrCanceled), "%s: Expected ErrCanceled. Got %v.", tt.name, err)
+		assert.Equal(t, "trapped\n", buf.String(), tt.name)
+		assert.Less(t, cmd.Duration(), 5*time.Second, tt.name)
+
+		cancel()
+	}
+}
+
+func Test_GracePeriodCtxKill(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	cmd := cmder.New("bash", "-c", `trap "" TERM; while :; do :; done`).
+		Ctx(ctx).
+		GracePeriod(100 * time.Millisecond).
+		Silent()
+
+	err := cmd.Run()
+
+	assert.True(t, errors.Is(err, cmder.ErrTimeout), "Expected ErrTimeout. Got %v.", err)
+	assert.Equal(t, syscall.SIGKILL, cmd.Signal())
+}
+
+func Test_TerminateRun(t *testing.T) {
+	cmd := cmder.New("bash", "-c", fmt.Sprintf(trapTerm, "TERM")).Silent()
+
+	var buf bytes.Buffer
+
+	errCh := make(chan error)
+	go func() { errCh <- cmd.Run(&buf) }()
+
+	// allow the process to start and the trap to be set
+	time.Sleep(200 * time.Millisecond)
+
+	err := cmd.Terminate(5 * time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, "trapped\n", buf.String())
+}
+
+func Test_CancelSignalDefaultGracePeriod(t *testing.T) {
+	orig := cmder.DefaultGracePeriod
+	defer func() { cmder.DefaultGracePeriod = orig }()
+
+	cmder.DefaultGracePeriod = 100 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	cmd := cmder.New("bash", "-c", `trap "" INT; while :; do :; done`).
+		Ctx(ctx).
+		CancelSignal(syscall.SIGINT).
+		Silent()
+
+	err := cmd.Run()
+
+	assert.True(t, errors.Is(err, cmder.ErrTimeout), "Expected ErrTimeout. Got %v.", err)
+	assert.Equal(t, syscall.SIGKILL, cmd.Signal())
+}